@@ -0,0 +1,113 @@
+package pipers
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// AIMD — адаптивный регулятор concurrency по принципу additive increase /
+// multiplicative decrease, как в управлении перегрузкой TCP.
+// Пока задачи завершаются без ошибок и укладываются в Latency, лимит растёт
+// примерно на единицу за каждые limit завершённых задач. Ошибка или превышение
+// Latency умножают лимит на Backoff. Отмена контекста перегрузкой не считается.
+// Один регулятор можно подключить к нескольким решателям: тогда они делят общий лимит,
+// то есть суммарное число их одновременно выполняемых задач не превышает Limit.
+type AIMD struct {
+	Min     int           // нижняя граница лимита (по умолчанию 1)
+	Max     int           // верхняя граница лимита (0 — без ограничения)
+	Initial int           // начальный лимит (по умолчанию Min)
+	Backoff float64       // множитель лимита при перегрузке (по умолчанию 0.5)
+	Latency time.Duration // порог времени выполнения задачи (0 — не учитывать)
+
+	once   sync.Once
+	mu     sync.Mutex
+	limit  float64
+	active int64         // задачи всех подключённых решателей, выполняемые сейчас
+	wake   chan struct{} // закрывается, когда освобождается место (см. give)
+}
+
+func (c *AIMD) init() {
+	c.once.Do(func() {
+		if c.Min < 1 {
+			c.Min = 1
+		}
+		if c.Backoff <= 0 || c.Backoff >= 1 {
+			c.Backoff = 0.5
+		}
+		c.limit = c.clamp(float64(c.Initial))
+		c.wake = make(chan struct{})
+	})
+}
+
+func (c *AIMD) clamp(limit float64) float64 {
+	if limit < float64(c.Min) {
+		return float64(c.Min)
+	}
+	if c.Max > 0 && limit > float64(c.Max) {
+		return float64(c.Max)
+	}
+	return limit
+}
+
+// Limit возвращает текущий лимит одновременно выполняемых задач.
+func (c *AIMD) Limit() int {
+	c.init()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return int(math.Floor(c.limit))
+}
+
+// take занимает n мест в общем лимите, если они свободны прямо сейчас.
+// Как и semaphore, пропускает задачу тяжелее лимита, когда свободны все места.
+func (c *AIMD) take(n int64) bool {
+	c.init()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.active+n <= int64(math.Floor(c.limit)) || c.active == 0 {
+		c.active += n
+		return true
+	}
+	return false
+}
+
+// hold занимает n мест без проверки лимита (см. semaphore.SetAdaptive).
+func (c *AIMD) hold(n int64) {
+	c.init()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.active += n
+}
+
+// give освобождает n мест и будит решатели, ожидающие в waiter.
+func (c *AIMD) give(n int64) {
+	c.init()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.active -= n
+	close(c.wake)
+	c.wake = make(chan struct{})
+}
+
+// waiter возвращает канал, который закроется при следующем освобождении мест.
+func (c *AIMD) waiter() <-chan struct{} {
+	c.init()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.wake
+}
+
+// observe учитывает завершение задачи, выполнявшейся latency, с ошибкой err.
+func (c *AIMD) observe(latency time.Duration, err error) {
+	if err != nil && isCanceled(err) {
+		return
+	}
+	c.init()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil || c.Latency > 0 && latency > c.Latency {
+		c.limit = c.clamp(c.limit * c.Backoff)
+	} else {
+		c.limit = c.clamp(c.limit + 1/c.limit)
+	}
+}
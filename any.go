@@ -0,0 +1,29 @@
+package pipers
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNoTasks возвращается FirstSuccess и Quorum, если в решателе нет ни одной задачи.
+var ErrNoTasks = errors.New("pipers: no tasks")
+
+// FirstSuccess запускает задачи и возвращает результат и индекс первой задачи,
+// завершившейся без ошибки. После этого контекст решателя отменяется,
+// поэтому остальные задачи, добавленные через AddFuncCtx, получают отменённый контекст.
+// Если без ошибки не завершилась ни одна задача, возвращается индекс -1
+// и AllFailedError с ошибками всех задач.
+func (ps *FliperSolver[T]) FirstSuccess() (T, int, error) {
+	res, err := ps.Quorum(1)
+	for i, v := range res {
+		return v, i, nil
+	}
+	var zero T
+	return zero, -1, err
+}
+
+// Any выполняет funcs одновременно и возвращает результат и индекс первой
+// успешно завершившейся функции, отменяя контекст остальных (см. FirstSuccess).
+func Any[T any](ctx context.Context, funcs ...func(context.Context) (T, error)) (T, int, error) {
+	return FromFuncsCtx(funcs...).Context(ctx).FirstSuccess()
+}
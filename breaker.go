@@ -0,0 +1,159 @@
+package pipers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen — ошибка задачи, не запущенной из-за разомкнутого Breaker.
+var ErrCircuitOpen = errors.New("pipers: circuit open")
+
+// BreakerState — состояние Breaker.
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // задачи выполняются как обычно
+	BreakerOpen                         // задачи сразу завершаются с ErrCircuitOpen
+	BreakerHalfOpen                     // пропускаются пробные задачи
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// Breaker — автоматический выключатель для задач, обращающихся к одной зависимости.
+// После Failures ошибок подряд он размыкается, и на время Cooldown задачи не запускаются,
+// а сразу завершаются с ошибкой ErrCircuitOpen. Затем он пропускает до Probes пробных
+// задач: если они выполнились без ошибок, выключатель замыкается, иначе снова размыкается.
+// Отмена контекста ошибкой не считается.
+// Один выключатель можно подключить к нескольким решателям.
+type Breaker struct {
+	Failures int           // число ошибок подряд для размыкания (по умолчанию 5)
+	Cooldown time.Duration // время в разомкнутом состоянии (по умолчанию 1s)
+	Probes   int           // число пробных задач в полуразомкнутом состоянии (по умолчанию 1)
+
+	once     sync.Once
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	opened   time.Time
+	probes   int // пробные задачи: выданные, а после выдачи всех — успешно завершившиеся
+	running  int // выполняющиеся пробные задачи
+}
+
+func (b *Breaker) init() {
+	b.once.Do(func() {
+		if b.Failures < 1 {
+			b.Failures = 5
+		}
+		if b.Cooldown <= 0 {
+			b.Cooldown = time.Second
+		}
+		if b.Probes < 1 {
+			b.Probes = 1
+		}
+	})
+}
+
+// State возвращает текущее состояние выключателя.
+func (b *Breaker) State() BreakerState {
+	b.init()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	return b.state
+}
+
+// advance переводит разомкнутый выключатель в полуразомкнутое состояние
+// по истечении Cooldown. Вызывается под b.mu.
+func (b *Breaker) advance() {
+	if b.state == BreakerOpen && time.Since(b.opened) >= b.Cooldown {
+		b.state, b.probes, b.running = BreakerHalfOpen, 0, 0
+	}
+}
+
+func (b *Breaker) trip() {
+	b.state, b.opened, b.failures = BreakerOpen, time.Now(), 0
+}
+
+// allow сообщает, можно ли запустить задачу, и для пробной задачи возвращает probe == true.
+func (b *Breaker) allow() (ok, probe bool) {
+	b.init()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	switch b.state {
+	case BreakerOpen:
+		return false, false
+	case BreakerHalfOpen:
+		if b.probes+b.running >= b.Probes {
+			return false, false
+		}
+		b.running++
+		return true, true
+	}
+	return true, false
+}
+
+// record учитывает итог задачи, пропущенной allow.
+func (b *Breaker) record(probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if probe {
+		b.running--
+	}
+	if err != nil && isCanceled(err) {
+		return
+	}
+	switch {
+	case err != nil && (probe || b.state == BreakerClosed):
+		if b.failures++; probe || b.failures >= b.Failures {
+			b.trip()
+		}
+	case err == nil && probe && b.state == BreakerHalfOpen:
+		if b.probes++; b.probes >= b.Probes {
+			b.state, b.failures = BreakerClosed, 0
+		}
+	case err == nil && b.state == BreakerClosed:
+		b.failures = 0
+	}
+}
+
+// Breaker подключает к решателю автоматический выключатель: перед запуском каждой
+// задачи решатель проверяет его состояние и при разомкнутом выключателе завершает
+// задачу с ошибкой ErrCircuitOpen, не вызывая её функцию. Результаты из кеша
+// (см. Cache) отдаются независимо от состояния выключателя.
+func (ps *FliperSolver[T]) Breaker(b *Breaker) *FliperSolver[T] {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.breaker = b
+	return ps
+}
+
+// guard выполняет задачу, если это разрешает выключатель решателя.
+func (ps *FliperSolver[T]) guard(ctx context.Context, t *task[T]) (T, error) {
+	ps.mu.Lock()
+	b := ps.breaker
+	ps.mu.Unlock()
+	if b == nil {
+		return ps.share(ctx, t)
+	}
+	ok, probe := b.allow()
+	if !ok {
+		var zero T
+		return zero, ErrCircuitOpen
+	}
+	res, err := ps.share(ctx, t)
+	b.record(probe, err)
+	return res, err
+}
@@ -0,0 +1,116 @@
+package pipers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrBulkheadFull — причина ошибки задачи, отклонённой переполненным Bulkhead.
+var ErrBulkheadFull = errors.New("pipers: bulkhead full")
+
+// Bulkhead — именованный общий для всего процесса пул, ограничивающий число
+// одновременно выполняемых задач всех подключённых к нему решателей
+// (например, всех обращений к одной базе данных).
+// Задача, для которой нет свободного места, ждёт в очереди; если очередь
+// тоже заполнена, задача завершается с ошибкой ErrBulkheadFull.
+type Bulkhead struct {
+	name  string
+	sem   *semaphore
+	mu    sync.Mutex
+	queue int
+	wait  int
+}
+
+var bulkheads = struct {
+	sync.Mutex
+	m map[string]*Bulkhead
+}{m: make(map[string]*Bulkhead)}
+
+// NewBulkhead возвращает пул с именем name, создавая его при первом обращении:
+// не более limit задач выполняются одновременно (0 — без ограничения)
+// и не более queue задач ждут своей очереди (0 — ожидание запрещено).
+// Повторные вызовы с тем же именем возвращают уже созданный пул без изменений.
+func NewBulkhead(name string, limit, queue int) *Bulkhead {
+	bulkheads.Lock()
+	defer bulkheads.Unlock()
+	if b, ok := bulkheads.m[name]; ok {
+		return b
+	}
+	b := &Bulkhead{name: name, sem: newSemaphore(int64(limit)), queue: queue}
+	bulkheads.m[name] = b
+	return b
+}
+
+// LookupBulkhead возвращает созданный ранее пул с именем name либо nil.
+func LookupBulkhead(name string) *Bulkhead {
+	bulkheads.Lock()
+	defer bulkheads.Unlock()
+	return bulkheads.m[name]
+}
+
+func (b *Bulkhead) Name() string {
+	return b.name
+}
+
+// SetLimit меняет лимит пула во время работы (0 — без ограничения).
+func (b *Bulkhead) SetLimit(limit int) {
+	b.sem.SetLimit(int64(limit))
+}
+
+// Waiting возвращает число задач, ожидающих места в пуле.
+func (b *Bulkhead) Waiting() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.wait
+}
+
+func (b *Bulkhead) acquire(ctx context.Context) error {
+	if b.sem.TryAcquire(1) {
+		return nil
+	}
+	b.mu.Lock()
+	if b.wait >= b.queue {
+		b.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrBulkheadFull, b.name)
+	}
+	b.wait++
+	b.mu.Unlock()
+
+	err := b.sem.Acquire(ctx, 1)
+
+	b.mu.Lock()
+	b.wait--
+	b.mu.Unlock()
+	return err
+}
+
+func (b *Bulkhead) release() {
+	b.sem.Release(1)
+}
+
+// Bulkhead подключает решатель к пулу b: задача занимает место в пуле на время
+// выполнения, а пока ждёт места в очереди пула, удерживает свой слот Concurrency.
+func (ps *FliperSolver[T]) Bulkhead(b *Bulkhead) *FliperSolver[T] {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.bulkhead = b
+	return ps
+}
+
+// isolate выполняет задачу в пуле решателя.
+func (ps *FliperSolver[T]) isolate(ctx context.Context, t *task[T]) (T, error) {
+	ps.mu.Lock()
+	b := ps.bulkhead
+	ps.mu.Unlock()
+	if b == nil {
+		return ps.guard(ctx, t)
+	}
+	if err := b.acquire(ctx); err != nil {
+		var zero T
+		return zero, err
+	}
+	defer b.release()
+	return ps.guard(ctx, t)
+}
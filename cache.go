@@ -0,0 +1,185 @@
+package pipers
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// CacheEntry — сохранённый в кеше итог задачи и время его получения.
+type CacheEntry[T any] struct {
+	Value T
+	Err   error
+	Time  time.Time
+}
+
+// Cache хранит итоги задач по их ключам (см. WithKey и FliperSolver.Cache).
+// Реализация должна быть безопасна для одновременного использования;
+// сроки жизни записей проверяет решатель, поэтому хранилище может их не учитывать.
+type Cache[T any] interface {
+	Get(key any) (CacheEntry[T], bool)
+	Set(key any, entry CacheEntry[T])
+}
+
+// CachePolicy задаёт сроки жизни записей кеша.
+// TTL — сколько успешный результат отдаётся из кеша без вызова задачи.
+// ErrorTTL — то же для ошибок (0 — ошибки не кешируются).
+// Stale — сколько ещё после истечения срока запись отдаётся из кеша, пока задача
+// в фоне получает свежий результат (stale-while-revalidate; 0 — не отдаётся).
+type CachePolicy struct {
+	TTL      time.Duration
+	ErrorTTL time.Duration
+	Stale    time.Duration
+}
+
+// cache связывает хранилище решателя с его политикой.
+type cache[T any] struct {
+	store  Cache[T]
+	policy CachePolicy
+
+	mu           sync.Mutex
+	revalidating map[any]bool
+}
+
+// lookup ищет запись по ключу. stale сообщает, что срок записи истёк
+// и её нужно обновить.
+func (c *cache[T]) lookup(key any) (e CacheEntry[T], ok, stale bool) {
+	e, ok = c.store.Get(key)
+	if !ok {
+		return e, false, false
+	}
+	ttl := c.policy.TTL
+	if e.Err != nil {
+		ttl = c.policy.ErrorTTL
+	}
+	age := time.Since(e.Time)
+	switch {
+	case age < ttl:
+		return e, true, false
+	case age < ttl+c.policy.Stale && e.Err == nil:
+		return e, true, true
+	}
+	return e, false, false
+}
+
+func (c *cache[T]) save(key any, res T, err error) {
+	if err != nil && (c.policy.ErrorTTL <= 0 || isCanceled(err) || errors.Is(err, ErrCircuitOpen)) {
+		return
+	}
+	c.store.Set(key, CacheEntry[T]{Value: res, Err: err, Time: time.Now()})
+}
+
+// revalidate обновляет запись в фоне; одновременно обновляется не более одной записи на ключ.
+func (c *cache[T]) revalidate(key any, fn func() (T, error)) {
+	c.mu.Lock()
+	if c.revalidating[key] {
+		c.mu.Unlock()
+		return
+	}
+	c.revalidating[key] = true
+	c.mu.Unlock()
+
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			delete(c.revalidating, key)
+			c.mu.Unlock()
+		}()
+		res, err := fn()
+		c.save(key, res, err)
+	}()
+}
+
+// Cache включает кеширование итогов задач с ключом (см. WithKey и FromArgsDedup):
+// пока запись в c не устарела по policy, задача не вызывается, а её итог берётся из кеша.
+// Одно хранилище можно использовать в нескольких решателях.
+// Устаревшая запись обновляется в фоне с context.Background, а не с контекстом решателя.
+func (ps *FliperSolver[T]) Cache(c Cache[T], policy CachePolicy) *FliperSolver[T] {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.cache = &cache[T]{store: c, policy: policy, revalidating: make(map[any]bool)}
+	return ps
+}
+
+// cached выполняет задачу с учётом кеша решателя.
+func (ps *FliperSolver[T]) cached(ctx context.Context, t *task[T], run func(ctx context.Context, t *task[T]) (T, error)) (T, error) {
+	ps.mu.Lock()
+	c := ps.cache
+	ps.mu.Unlock()
+	if c == nil || t.key == nil {
+		return run(ctx, t)
+	}
+	if e, ok, stale := c.lookup(t.key); ok {
+		if stale {
+			// обновляем копией задачи, чтобы не затрагивать её статистику
+			rt := &task[T]{index: t.index, fn: t.fn, taskOptions: t.taskOptions}
+			c.revalidate(t.key, func() (T, error) {
+				return run(context.Background(), rt)
+			})
+		}
+		return e.Value, e.Err
+	}
+	res, err := run(ctx, t)
+	c.save(t.key, res, err)
+	return res, err
+}
+
+// LRU — кеш в памяти, хранящий не более size последних использованных записей.
+type LRU[T any] struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List
+	items map[any]*list.Element
+}
+
+type lruItem[T any] struct {
+	key   any
+	entry CacheEntry[T]
+}
+
+func NewLRU[T any](size int) *LRU[T] {
+	if size < 1 {
+		size = 1
+	}
+	return &LRU[T]{
+		size:  size,
+		order: list.New(),
+		items: make(map[any]*list.Element),
+	}
+}
+
+func (c *LRU[T]) Get(key any) (CacheEntry[T], bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return CacheEntry[T]{}, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*lruItem[T]).entry, true
+}
+
+func (c *LRU[T]) Set(key any, entry CacheEntry[T]) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		el.Value.(*lruItem[T]).entry = entry
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.order.PushFront(&lruItem[T]{key: key, entry: entry})
+	if c.order.Len() > c.size {
+		el := c.order.Back()
+		c.order.Remove(el)
+		delete(c.items, el.Value.(*lruItem[T]).key)
+	}
+}
+
+// Len возвращает число записей в кеше.
+func (c *LRU[T]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
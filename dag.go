@@ -0,0 +1,338 @@
+package pipers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// CycleError возвращается DAG.Build, если зависимости узлов образуют цикл.
+// Path содержит имена узлов цикла; первый и последний элементы совпадают.
+type CycleError struct {
+	Path []string
+}
+
+func (e *CycleError) Error() string {
+	return "pipers: dependency cycle: " + strings.Join(e.Path, " -> ")
+}
+
+// NodeError связывает ошибку узла DAG с его именем.
+type NodeError struct {
+	Node string
+	Err  error
+}
+
+func (e *NodeError) Error() string {
+	return fmt.Sprintf("node %q: %v", e.Node, e.Err)
+}
+
+func (e *NodeError) Unwrap() error {
+	return e.Err
+}
+
+// UpstreamError — ошибка узла, пропущенного из-за того, что не выполнилась
+// одна из его зависимостей (прямых или транзитивных). Node — имя этой зависимости,
+// Err — её ошибка.
+type UpstreamError struct {
+	Node string
+	Err  error
+}
+
+func (e *UpstreamError) Error() string {
+	return fmt.Sprintf("upstream %q: %v", e.Node, e.Err)
+}
+
+func (e *UpstreamError) Unwrap() error {
+	return e.Err
+}
+
+// NodeSettlement — итог узла DAG (см. DAG.Settled).
+type NodeSettlement[T any] struct {
+	Name string
+	Deps []string
+	Settlement[T]
+}
+
+type dagNode[T any] struct {
+	name string
+	deps []string
+	fn   func(ctx context.Context, deps []T) (T, error)
+
+	upstream   []*dagNode[T]
+	downstream []*dagNode[T]
+	waiting    int // число ещё не выполненных зависимостей
+	index      int // индекс задачи в решателе, -1 — задача не добавлялась
+	skipped    error
+}
+
+// DAG выполняет задачи, зависящие от результатов других задач.
+// Узел запускается, только когда успешно выполнились все его зависимости,
+// и получает их результаты. Если зависимость завершилась с ошибкой, все зависящие
+// от неё узлы пропускаются со статусом StatusSkipped.
+// Узлы выполняются внутренним FliperSolver, поэтому для них действуют
+// ограничение Concurrency и лимит ошибок FirstError/FirstNErrors.
+type DAG[T any] struct {
+	ps     *FliperSolver[T]
+	nodes  []*dagNode[T]
+	byName map[string]*dagNode[T]
+	order  []*dagNode[T] // узлы в топологическом порядке
+	byTask map[int]*dagNode[T]
+
+	once       sync.Once
+	err        error
+	ready      chan *dagNode[T]
+	unresolved int
+	mu         sync.Mutex
+}
+
+func NewDAG[T any]() *DAG[T] {
+	return &DAG[T]{
+		ps:     &FliperSolver[T]{},
+		byName: make(map[string]*dagNode[T]),
+		byTask: make(map[int]*dagNode[T]),
+	}
+}
+
+// Node добавляет узел name, зависящий от узлов deps. f получает результаты
+// зависимостей в том же порядке, в котором они перечислены в deps.
+// Узлы можно объявлять в любом порядке: зависимости проверяются в Build.
+func (d *DAG[T]) Node(name string, f func(ctx context.Context, deps []T) (T, error), deps ...string) *DAG[T] {
+	d.nodes = append(d.nodes, &dagNode[T]{name: name, deps: deps, fn: f, index: -1})
+	return d
+}
+
+func (d *DAG[T]) Context(ctx context.Context) *DAG[T] {
+	d.ps.Context(ctx)
+	return d
+}
+
+func (d *DAG[T]) Concurrency(concurrency int) *DAG[T] {
+	d.ps.Concurrency(concurrency)
+	return d
+}
+
+// Build проверяет граф: имена узлов уникальны, все зависимости объявлены
+// и среди них нет циклов (CycleError). Методы запуска вызывают Build сами,
+// поэтому явный вызов нужен только для проверки графа заранее.
+func (d *DAG[T]) Build() error {
+	d.once.Do(func() {
+		d.err = d.build()
+	})
+	return d.err
+}
+
+func (d *DAG[T]) build() error {
+	for _, n := range d.nodes {
+		if _, ok := d.byName[n.name]; ok {
+			return fmt.Errorf("pipers: duplicate node %q", n.name)
+		}
+		d.byName[n.name] = n
+	}
+	for _, n := range d.nodes {
+		for _, name := range n.deps {
+			dep, ok := d.byName[name]
+			if !ok {
+				return fmt.Errorf("pipers: node %q depends on unknown node %q", n.name, name)
+			}
+			n.upstream = append(n.upstream, dep)
+			dep.downstream = append(dep.downstream, n)
+		}
+		n.waiting = len(n.upstream)
+	}
+
+	// обход в глубину: узел попадает в order после всех своих зависимостей
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[*dagNode[T]]int, len(d.nodes))
+	var path []string
+	var visit func(n *dagNode[T]) error
+	visit = func(n *dagNode[T]) error {
+		switch state[n] {
+		case visited:
+			return nil
+		case visiting:
+			for k, name := range path {
+				if name == n.name {
+					return &CycleError{Path: append(path[k:len(path):len(path)], n.name)}
+				}
+			}
+		}
+		state[n] = visiting
+		path = append(path, n.name)
+		for _, dep := range n.upstream {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[n] = visited
+		d.order = append(d.order, n)
+		return nil
+	}
+	for _, n := range d.nodes {
+		if err := visit(n); err != nil {
+			return err
+		}
+	}
+
+	d.unresolved = len(d.nodes)
+	d.ready = make(chan *dagNode[T], len(d.nodes))
+	for _, n := range d.order {
+		if n.waiting == 0 {
+			d.ready <- n
+		}
+	}
+	if d.unresolved == 0 {
+		close(d.ready)
+	}
+	d.ps.source = &source{next: d.next}
+	return nil
+}
+
+// next добавляет в решатель очередной узел, все зависимости которого выполнены.
+func (d *DAG[T]) next(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case n, ok := <-d.ready:
+		if !ok {
+			return false
+		}
+		i := d.ps.add(func(ctx context.Context, _ int) (T, error) {
+			return d.call(ctx, n)
+		}, nil)
+		d.mu.Lock()
+		n.index = i
+		d.byTask[i] = n
+		d.mu.Unlock()
+		pp, _ := d.ps.snapshot()
+		go func() {
+			select {
+			case <-pp[i].Done():
+				d.resolve(n, d.ps.settle(i).Err)
+			case <-ctx.Done():
+			}
+		}()
+		return true
+	}
+}
+
+// call выполняет узел с результатами его зависимостей.
+func (d *DAG[T]) call(ctx context.Context, n *dagNode[T]) (T, error) {
+	deps := make([]T, len(n.upstream))
+	for k, dep := range n.upstream {
+		d.mu.Lock()
+		i := dep.index
+		d.mu.Unlock()
+		deps[k] = d.ps.settle(i).Value
+	}
+	return n.fn(ctx, deps)
+}
+
+// resolve учитывает завершение узла: запускает зависимые узлы, готовые к выполнению,
+// либо пропускает их, если узел завершился с ошибкой.
+func (d *DAG[T]) resolve(n *dagNode[T], err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.unresolved--
+	if err != nil {
+		d.skip(n, &UpstreamError{Node: n.name, Err: err})
+	} else {
+		for _, next := range n.downstream {
+			if next.waiting--; next.waiting == 0 && next.skipped == nil {
+				d.ready <- next
+			}
+		}
+	}
+	if d.unresolved == 0 {
+		close(d.ready)
+	}
+}
+
+// skip пропускает все узлы, зависящие от n. Вызывается под d.mu.
+func (d *DAG[T]) skip(n *dagNode[T], err error) {
+	for _, next := range n.downstream {
+		if next.skipped == nil {
+			next.skipped = err
+			d.unresolved--
+			d.skip(next, err)
+		}
+	}
+}
+
+// errors переводит ошибки задач решателя в ошибки узлов.
+func (d *DAG[T]) errors(errs Errors) Errors {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for k, err := range errs {
+		if te, ok := err.(*TaskError); ok {
+			errs[k] = &NodeError{Node: d.byTask[te.Index].name, Err: te.Err}
+		}
+	}
+	return errs
+}
+
+func (d *DAG[T]) FirstError() error {
+	if errs := d.FirstNErrors(1); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// FirstNErrors выполняет граф до n ошибок (0 — без ограничения).
+// Пропущенные узлы ошибками не считаются. Ошибки узлов имеют тип NodeError.
+func (d *DAG[T]) FirstNErrors(n int) Errors {
+	if err := d.Build(); err != nil {
+		return Errors{err}
+	}
+	return d.errors(d.ps.FirstNErrors(n))
+}
+
+func (d *DAG[T]) ErrorsAll() Errors {
+	return d.FirstNErrors(0)
+}
+
+// Results возвращает результаты успешно выполненных узлов по их именам.
+func (d *DAG[T]) Results() map[string]T {
+	res := make(map[string]T)
+	for _, s := range d.Settled() {
+		if s.Status == StatusSucceeded {
+			res[s.Name] = s.Value
+		}
+	}
+	return res
+}
+
+func (d *DAG[T]) Resolve() (map[string]T, error) {
+	err := d.FirstError()
+	return d.Results(), err
+}
+
+// Settled возвращает итоги всех узлов на текущий момент в топологическом порядке:
+// каждый узел следует за всеми своими зависимостями. Index — позиция узла в этом порядке.
+// Пропущенные узлы имеют статус StatusSkipped и ошибку UpstreamError.
+func (d *DAG[T]) Settled() []NodeSettlement[T] {
+	if d.Build() != nil {
+		return nil
+	}
+	res := make([]NodeSettlement[T], len(d.order))
+	for k, n := range d.order {
+		d.mu.Lock()
+		i, skipped := n.index, n.skipped
+		d.mu.Unlock()
+		s := Settlement[T]{Status: StatusNotStarted}
+		switch {
+		case skipped != nil:
+			s.Status, s.Err = StatusSkipped, skipped
+		case i >= 0:
+			s = d.ps.settle(i)
+		}
+		s.Index = k
+		res[k] = NodeSettlement[T]{Name: n.name, Deps: n.deps, Settlement: s}
+	}
+	return res
+}
@@ -0,0 +1,71 @@
+package pipers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kozhurkin/singleflight"
+)
+
+// Dedup объединяет задачи с ключом (см. WithKey) с одновременно выполняемыми
+// задачами других решателей, использующих ту же группу g: пока задача с ключом
+// выполняется в одном решателе, остальные не запускают свою функцию, а ждут её результат.
+// Задача выполняется с контекстом и политиками того решателя, который запустил её первым.
+// Если его контекст отменён раньше, чем задача завершилась, решатели, которые ещё ждут
+// результат, запускают её заново, а не получают чужую ошибку отмены.
+// Внутри одного решателя задачи с одинаковым ключом объединяются и без Dedup.
+func (ps *FliperSolver[T]) Dedup(g *singleflight.Group[any, T]) *FliperSolver[T] {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.group = g
+	return ps
+}
+
+// abandoned — ошибка общей задачи, решатель которой отменил свой контекст.
+// Её получают только ожидающие решатели группы Dedup.
+type abandoned struct {
+	err error
+}
+
+func (e *abandoned) Error() string {
+	return e.err.Error()
+}
+
+func (e *abandoned) Unwrap() error {
+	return e.err
+}
+
+// share выполняет задачу через группу Dedup, если у задачи есть ключ.
+func (ps *FliperSolver[T]) share(ctx context.Context, t *task[T]) (T, error) {
+	ps.mu.Lock()
+	g := ps.group
+	ps.mu.Unlock()
+	if g == nil || t.key == nil {
+		return ps.execute(ctx, t)
+	}
+
+	var last *abandoned
+	for {
+		ran := false
+		res, err := g.Do(t.key, func() (T, error) {
+			ran = true
+			res, err := ps.execute(ctx, t)
+			if err != nil && ctx.Err() != nil {
+				err = &abandoned{err}
+			}
+			return res, err
+		})
+		var ab *abandoned
+		if !errors.As(err, &ab) {
+			return res, err
+		}
+		if ran || ctx.Err() != nil {
+			return res, ab.err
+		}
+		if ab == last {
+			// группа закешировала ошибку отмены: выполняем задачу без неё
+			return ps.execute(ctx, t)
+		}
+		last = ab
+	}
+}
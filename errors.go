@@ -1,9 +1,86 @@
 package pipers
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TaskError связывает ошибку задачи с её индексом в наборе.
+// Все ошибки задач, которые возвращают ErrorsChan, FirstError и FirstNErrors,
+// имеют этот тип; errors.Is/errors.As продолжают работать с исходной ошибкой.
+type TaskError struct {
+	Index int
+	Err   error
+}
+
+func (e *TaskError) Error() string {
+	return fmt.Sprintf("task %d: %v", e.Index, e.Err)
+}
+
+func (e *TaskError) Unwrap() error {
+	return e.Err
+}
 
 type Errors []error
 
 func (errs Errors) Join() error {
 	return errors.Join(errs...)
 }
+
+// AllFailedError возвращается FirstSuccess, когда ни одна задача не завершилась
+// без ошибки, и Quorum, когда из-за ошибок задач кворум стал недостижим.
+// Errs — ошибки задач в порядке их появления; errors.Is/errors.As проверяют каждую из них.
+type AllFailedError struct {
+	Errs Errors
+}
+
+func (e *AllFailedError) Error() string {
+	msgs := make([]string, len(e.Errs))
+	for k, err := range e.Errs {
+		msgs[k] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (e *AllFailedError) Unwrap() []error {
+	return e.Errs
+}
+
+// ByIndex возвращает исходные ошибки задач по их индексам.
+// Ошибки, не привязанные к задаче (например, ошибка контекста), пропускаются.
+func (errs Errors) ByIndex() map[int]error {
+	res := make(map[int]error, len(errs))
+	for _, err := range errs {
+		var te *TaskError
+		if errors.As(err, &te) {
+			res[te.Index] = te.Err
+		}
+	}
+	return res
+}
+
+// Indexes возвращает индексы задач, завершившихся с ошибкой, в порядке появления ошибок.
+func (errs Errors) Indexes() []int {
+	res := make([]int, 0, len(errs))
+	for _, err := range errs {
+		var te *TaskError
+		if errors.As(err, &te) {
+			res = append(res, te.Index)
+		}
+	}
+	return res
+}
+
+// Attempts возвращает историю попыток для задач, завершившихся с RetryError,
+// в виде отображения индекса задачи в ошибки её попыток.
+func (errs Errors) Attempts() map[int][]error {
+	res := make(map[int][]error)
+	for _, err := range errs {
+		var re *RetryError
+		if errors.As(err, &re) {
+			res[re.Index] = re.Attempts
+		}
+	}
+	return res
+}
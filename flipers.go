@@ -3,7 +3,6 @@ package pipers
 import (
 	"context"
 	"sync"
-	"sync/atomic"
 
 	"github.com/kozhurkin/singleflight/flight"
 )
@@ -17,56 +16,43 @@ type Flipers[T any] []*flight.Flight[T]
 // Если concurrency == 0 или больше числа задач, все задачи запускаются
 // параллельно без ограничения.
 // Остановка дальнейших запусков контролируется только переданным контекстом.
+// Паника в функции Flight не перехватывается (см. PanicError).
 func (pp Flipers[T]) Run(ctx context.Context, concurrency, errlimit int) Flipers[T] {
+	pp.run(ctx, concurrency, errlimit)
+	return pp
+}
+
+// run реализует Run и возвращает канал, который закрывается, когда планировщик
+// перестаёт запускать новые Flight. После его закрытия p.Started() больше не меняется.
+func (pp Flipers[T]) run(ctx context.Context, concurrency, errlimit int) <-chan struct{} {
 	if concurrency == 0 || concurrency >= len(pp) {
 		for _, p := range pp {
-			go p.Run()
+			p.RunAsync()
 		}
-		return pp
+		scheduled := make(chan struct{})
+		close(scheduled)
+		return scheduled
 	}
-	go func() {
-		traffic := make(chan struct{}, concurrency)
-		defer func() {
-			printDebug("close(traffic)")
-			close(traffic)
-		}()
-
-		var errorCount int32
-		var errorLimit int32 = int32(errlimit)
-
-		ctx, cancel := context.WithCancel(ctx)
-		defer cancel()
-
-		for _, p := range pp {
-			p := p
-			select {
-			case <-ctx.Done():
-				return // context canceled
-			case traffic <- struct{}{}:
-				go func() {
-					p.Run()
-					_, err := p.Wait()
-					if err != nil && errorLimit > 0 && atomic.AddInt32(&errorCount, 1) >= errorLimit {
-						cancel()
-					} else {
-						<-traffic
-					}
-				}()
-			}
+	var cursor int
+	scheduled, _ := schedule(ctx, nopLogger{}, newSemaphore(int64(concurrency)), nil, errlimit, func(context.Context) (int, *flight.Flight[T], bool) {
+		if cursor == len(pp) {
+			return 0, nil, false
 		}
-	}()
-	return pp
+		cursor++
+		return cursor - 1, pp[cursor-1], true
+	}, nil)
+	return scheduled
 }
 
 // ErrorsChan возвращает буферизированный канал ошибок, в который будут
-// отправляться ошибки завершившихся Flight. Канал закрывается после
-// завершения всех полётов или при завершении контекста.
+// отправляться ошибки завершившихся Flight, обёрнутые в TaskError с индексом Flight.
+// Канал закрывается после завершения всех полётов или при завершении контекста.
 func (pp Flipers[T]) ErrorsChan(ctx context.Context) chan error {
 	errchan := make(chan error, len(pp))
 	wg := sync.WaitGroup{}
 
-	for _, p := range pp {
-		p := p
+	for i, p := range pp {
+		i, p := i, p
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
@@ -79,13 +65,12 @@ func (pp Flipers[T]) ErrorsChan(ctx context.Context) chan error {
 				// already done
 			}
 			if _, err := p.Wait(); err != nil {
-				errchan <- err
+				errchan <- &TaskError{Index: i, Err: err}
 			}
 		}()
 	}
 	go func() {
 		wg.Wait()
-		printDebug("close(errchan)")
 		close(errchan)
 	}()
 
@@ -3,13 +3,37 @@ package pipers
 import (
 	"context"
 	"sync"
+	"time"
 
+	"github.com/kozhurkin/singleflight"
 	"github.com/kozhurkin/singleflight/flight"
 )
 
 type FliperSolver[T any] struct {
 	flipers     Flipers[T]
+	tasks       []*task[T]
 	concurrency int
+	sem         *semaphore
+	adaptive    *AIMD
+	rate        *RateLimiter
+	weightLimit int64
+	wsem        *semaphore
+	prioritized bool
+	aging       time.Duration
+	retry       *RetryPolicy
+	timeout     time.Duration
+	hedge       *Hedge
+	hedges      hedges
+	keys        map[any]int
+	group       *singleflight.Group[any, T]
+	cache       *cache[T]
+	breaker     *Breaker
+	bulkhead    *Bulkhead
+	hooks       []Hooks
+	metrics     Metrics
+	tracer      Tracer
+	logger      logger
+	source      *source
 	context     context.Context
 	mu          sync.Mutex
 }
@@ -29,42 +53,431 @@ func (ps *FliperSolver[T]) Context(ctx context.Context) *FliperSolver[T] {
 	return ps
 }
 
+// Concurrency ограничивает число одновременно выполняемых задач (0 — без ограничения).
+// Лимит можно менять и во время выполнения: при увеличении ожидающие задачи
+// запускаются сразу, при уменьшении новые задачи придерживаются, пока число
+// выполняемых не опустится ниже лимита. Уже запущенные задачи не прерываются.
 func (ps *FliperSolver[T]) Concurrency(concurrency int) *FliperSolver[T] {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
 	ps.concurrency = concurrency
+	if ps.sem != nil {
+		ps.sem.SetLimit(int64(concurrency))
+	}
+	return ps
+}
+
+// Adaptive подключает регулятор AIMD, который подбирает лимит одновременно
+// выполняемых задач по их времени выполнения и ошибкам. Пока регулятор подключён,
+// значение Concurrency не действует; nil отключает регулятор.
+func (ps *FliperSolver[T]) Adaptive(c *AIMD) *FliperSolver[T] {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.adaptive = c
+	if ps.sem != nil {
+		ps.sem.SetAdaptive(c)
+	}
+	return ps
+}
+
+// ConcurrencyLimit возвращает действующий лимит одновременно выполняемых задач,
+// в том числе выбранный регулятором Adaptive.
+func (ps *FliperSolver[T]) ConcurrencyLimit() int {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if ps.adaptive != nil {
+		return ps.adaptive.Limit()
+	}
+	return ps.concurrency
+}
+
+// WeightLimit ограничивает суммарный вес одновременно выполняемых задач
+// (например, занимаемую память). Вес задаётся через AddFuncWeighted или WithWeight,
+// по умолчанию он равен 1. Задачи допускаются строго по очереди, поэтому тяжёлая
+// задача не голодает: пока она ждёт освобождения веса, следующие за ней не запускаются.
+// Задача тяжелее самого лимита запускается, когда не выполняется ни одной другой.
+// Как и Concurrency, лимит можно менять во время выполнения; 0 снимает ограничение.
+func (ps *FliperSolver[T]) WeightLimit(budget int64) *FliperSolver[T] {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.weightLimit = budget
+	if ps.wsem != nil {
+		ps.wsem.SetLimit(budget)
+	}
+	return ps
+}
+
+// Aging задаёт защиту от голодания задач с низким приоритетом (см. WithPriority):
+// за каждые d ожидания в очереди приоритет задачи увеличивается на 1.
+func (ps *FliperSolver[T]) Aging(d time.Duration) *FliperSolver[T] {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.aging = d
+	return ps
+}
+
+// RateLimit ограничивает частоту вызовов задач: не больше rps в секунду
+// с возможным всплеском до burst. Действует вместе с Concurrency.
+// Токен нужен каждой попытке: повторам Retry, дубликатам Hedge и фоновому
+// обновлению кеша тоже. Задачам, итог которых берётся из кеша (см. Cache), токен не нужен.
+// Flight, добавленные через Add, получают токен только при запуске.
+func (ps *FliperSolver[T]) RateLimit(rps float64, burst int) *FliperSolver[T] {
+	return ps.RateLimiter(NewRateLimiter(rps, burst))
+}
+
+// RateLimiter подключает готовый RateLimiter, например общий для нескольких решателей.
+func (ps *FliperSolver[T]) RateLimiter(rl *RateLimiter) *FliperSolver[T] {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.rate = rl
+	return ps
+}
+
+// gates возвращает ограничения, которые задача проходит после получения слота concurrency:
+// вес и токен RateLimiter.
+func (ps *FliperSolver[T]) gates() []gate {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if ps.wsem == nil {
+		ps.wsem = newSemaphore(ps.weightLimit)
+	}
+	gates := []gate{weights{sem: ps.wsem, of: ps.weight}}
+	if ps.rate != nil {
+		gates = append(gates, admission[T]{rl: ps.rate, ps: ps})
+	}
+	return gates
+}
+
+// semaphore возвращает семафор решателя, создавая его при первом запуске.
+// Семафор общий для всех запусков, чтобы Concurrency действовал на уже работающий решатель.
+func (ps *FliperSolver[T]) semaphore() *semaphore {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if ps.sem == nil {
+		ps.sem = newSemaphore(int64(ps.concurrency))
+		ps.sem.SetAdaptive(ps.adaptive)
+	}
+	return ps.sem
+}
+
+// Retry задаёт политику повторов для всех задач, у которых нет собственной (см. WithRetry).
+func (ps *FliperSolver[T]) Retry(policy RetryPolicy) *FliperSolver[T] {
+	ps.retry = &policy
+	return ps
+}
+
+// TaskTimeout ограничивает время выполнения каждой задачи, у которой нет
+// собственного таймаута (см. WithTimeout). Задача, не уложившаяся в d,
+// завершается с ошибкой TimeoutError, не затрагивая остальные задачи.
+// Если обработчик не реагирует на отмену контекста, он продолжает работать в фоне,
+// а его слот Concurrency (и вес WeightLimit) остаётся занятым, пока он не вернётся.
+// При использовании вместе с Retry таймаут распространяется на все попытки задачи.
+func (ps *FliperSolver[T]) TaskTimeout(d time.Duration) *FliperSolver[T] {
+	ps.timeout = d
 	return ps
 }
 
+// Hedge включает подстраховочные запуски задач, у которых нет собственной
+// настройки (см. WithHedge): если попытка задачи не завершилась за задержку h,
+// запускается дубликат той же функции, и побеждает первый успешный результат,
+// а проигравший получает отменённый контекст. Дубликат считается отдельной попыткой
+// в Settlement.Attempts, но не занимает дополнительный слот Concurrency.
+func (ps *FliperSolver[T]) Hedge(h Hedge) *FliperSolver[T] {
+	ps.hedge = &h
+	return ps
+}
+
+// Hedges возвращает, сколько раз за всё время работы решателя запускались дубликаты задач.
+func (ps *FliperSolver[T]) Hedges() int {
+	return ps.hedges.count()
+}
+
+// Add добавляет готовый Flight. Такой Flight выполняется как есть:
+// политики решателя (например, Retry) к нему не применяются, а паника
+// в его функции не превращается в PanicError.
 func (ps *FliperSolver[T]) Add(p *flight.Flight[T]) *FliperSolver[T] {
 	ps.mu.Lock()
 	defer ps.mu.Unlock()
+	ps.tasks = append(ps.tasks, &task[T]{index: len(ps.flipers), queued: time.Now()})
 	ps.flipers = append(ps.flipers, p)
 	return ps
 }
 
-func (ps *FliperSolver[T]) AddFunc(f func() (T, error)) *FliperSolver[T] {
-	p := flight.NewFlight(f)
-	return ps.Add(p)
+func (ps *FliperSolver[T]) AddFunc(f func() (T, error), opts ...TaskOption) *FliperSolver[T] {
+	return ps.AddFuncCtx(func(context.Context) (T, error) {
+		return f()
+	}, opts...)
+}
+
+// AddFuncWeighted добавляет задачу с весом weight (см. WeightLimit).
+func (ps *FliperSolver[T]) AddFuncWeighted(weight int64, f func() (T, error), opts ...TaskOption) *FliperSolver[T] {
+	return ps.AddFunc(f, append([]TaskOption{WithWeight(weight)}, opts...)...)
 }
 
-func (ps *FliperSolver[T]) AddFuncCtx(f func(ctx context.Context) (T, error)) *FliperSolver[T] {
-	p := flight.NewFlight(func() (T, error) {
-		return f(ps.context)
+func (ps *FliperSolver[T]) AddFuncCtx(f func(ctx context.Context) (T, error), opts ...TaskOption) *FliperSolver[T] {
+	ps.add(func(ctx context.Context, _ int) (T, error) {
+		return f(ctx)
+	}, opts)
+	return ps
+}
+
+// add добавляет задачу и возвращает её индекс; f получает этот же индекс.
+func (ps *FliperSolver[T]) add(f func(ctx context.Context, i int) (T, error), opts []TaskOption) int {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	t := &task[T]{index: len(ps.flipers), queued: time.Now()}
+	t.fn = func(ctx context.Context) (T, error) {
+		return f(ctx, t.index)
+	}
+	for _, opt := range opts {
+		opt(&t.taskOptions)
+	}
+	if t.key != nil {
+		if j, ok := ps.keys[t.key]; ok {
+			ps.tasks = append(ps.tasks, ps.tasks[j])
+			ps.flipers = append(ps.flipers, ps.flipers[j])
+			return len(ps.flipers) - 1
+		}
+		if ps.keys == nil {
+			ps.keys = make(map[any]int)
+		}
+		ps.keys[t.key] = t.index
+	}
+	if t.priority != 0 {
+		ps.prioritized = true
+	}
+	ps.tasks = append(ps.tasks, t)
+	ps.flipers = append(ps.flipers, flight.NewFlight(func() (T, error) {
+		return ps.observe(t, func() (T, error) {
+			return ps.traced(t, func(ctx context.Context) (T, error) {
+				return ps.cached(ctx, t, ps.isolate)
+			})
+		})
+	}))
+	return t.index
+}
+
+// pick выбирает из ожидающих запуска задач ту, что должна стартовать следующей:
+// с наибольшим приоритетом с учётом Aging, а при равенстве — добавленную раньше.
+// Вызывается под ps.mu.
+func (ps *FliperSolver[T]) pick(pending []int) int {
+	if !ps.prioritized {
+		return 0
+	}
+	now := time.Now()
+	best, bestPriority := 0, 0
+	for k, i := range pending {
+		t := ps.tasks[i]
+		priority := t.priority
+		if ps.aging > 0 {
+			priority += int(now.Sub(t.queued) / ps.aging)
+		}
+		if k == 0 || priority > bestPriority {
+			best, bestPriority = k, priority
+		}
+	}
+	return best
+}
+
+// weight возвращает вес i-й задачи.
+func (ps *FliperSolver[T]) weight(i int) int64 {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if w := ps.tasks[i].weight; w > 0 {
+		return w
+	}
+	return 1
+}
+
+// snapshot возвращает задачи, добавленные на текущий момент.
+// Задачи могут добавляться во время выполнения (см. FromChan), поэтому
+// методы, обходящие все задачи, работают со снимком.
+func (ps *FliperSolver[T]) snapshot() (Flipers[T], []*task[T]) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	n := len(ps.flipers)
+	return ps.flipers[:n:n], ps.tasks[:n:n]
+}
+
+// settle возвращает итог i-й задачи на текущий момент.
+func (ps *FliperSolver[T]) settle(i int) Settlement[T] {
+	ps.mu.Lock()
+	p, t := ps.flipers[i], ps.tasks[i]
+	ps.mu.Unlock()
+	s := settle(i, p)
+	t.stats(&s)
+	return s
+}
+
+// run запускает задачи решателя, включая поступающие из источника (см. FromChan),
+// и возвращает канал индексов завершившихся задач. Канал закрывается, когда запуски
+// прекращены и все запущенные задачи завершились. После закрытия quit индексы
+// в канал больше не отправляются. Источник вычитывается только при первом запуске.
+func (ps *FliperSolver[T]) run(ctx context.Context, errlimit int, quit <-chan struct{}) <-chan int {
+	ps.mu.Lock()
+	src := ps.source
+	ps.source = nil
+	done := make(chan int, len(ps.flipers))
+	ps.mu.Unlock()
+
+	log := ps.log()
+	var cursor int
+	var pending []int
+	next := func(ctx context.Context) (int, *flight.Flight[T], bool) {
+		for {
+			ps.mu.Lock()
+			for ; cursor < len(ps.flipers); cursor++ {
+				pending = append(pending, cursor)
+				log.Debug("pipers: task queued", "index", cursor, "priority", ps.tasks[cursor].priority)
+			}
+			if len(pending) > 0 {
+				k := ps.pick(pending)
+				i := pending[k]
+				pending = append(pending[:k], pending[k+1:]...)
+				p := ps.flipers[i]
+				ps.mu.Unlock()
+				return i, p, true
+			}
+			ps.mu.Unlock()
+			if src == nil || !src.next(ctx) {
+				return 0, nil, false
+			}
+		}
+	}
+
+	// слоты задачи, брошенной по таймауту, освобождаются, только когда вернётся её обработчик
+	wait := func(i int, f func()) {
+		if i < 0 {
+			f()
+			return
+		}
+		ps.mu.Lock()
+		t := ps.tasks[i]
+		ps.mu.Unlock()
+		t.whenIdle(f)
+	}
+	gates := ps.gates()
+	for k, g := range gates {
+		gates[k] = held{g, wait}
+	}
+
+	scheduled, finished := schedule(ctx, log, held{ps.semaphore(), wait}, gates, errlimit, next, func(i int) {
+		select {
+		case done <- i:
+		case <-quit:
+		}
 	})
-	return ps.Add(p)
+
+	go func() {
+		<-scheduled
+		if src != nil && src.stop != nil {
+			src.stop()
+		}
+		<-finished
+		log.Debug("pipers: all tasks finished")
+		close(done)
+	}()
+
+	return done
+}
+
+// execute выполняет задачу с учётом её настроек и настроек решателя.
+// Паника в обработчике перехватывается и возвращается как PanicError.
+func (ps *FliperSolver[T]) execute(ctx context.Context, t *task[T]) (T, error) {
+	t.begin()
+	defer t.finish()
+
+	call := t.call
+
+	ps.mu.Lock()
+	rl := ps.rate
+	ps.mu.Unlock()
+	if rl != nil {
+		call = func(ctx context.Context) (T, error) {
+			if err := t.pay(ctx, rl); err != nil {
+				var zero T
+				return zero, err
+			}
+			return t.call(ctx)
+		}
+	}
+
+	hedge := ps.hedge
+	if t.hedge != nil {
+		hedge = t.hedge
+	}
+	if hedge != nil {
+		attempt := call
+		call = func(ctx context.Context) (T, error) {
+			return t.runHedge(ctx, hedge, &ps.hedges, attempt)
+		}
+	}
+
+	fn := call
+
+	policy := ps.retry
+	if t.retry != nil {
+		policy = t.retry
+	}
+	if policy != nil && policy.MaxAttempts > 1 {
+		fn = func(ctx context.Context) (T, error) {
+			return t.runRetry(ctx, policy, call)
+		}
+	}
+
+	timeout := ps.timeout
+	if t.timeout > 0 {
+		timeout = t.timeout
+	}
+	if timeout > 0 {
+		return t.runTimeout(ctx, timeout, fn)
+	}
+
+	return fn(ctx)
 }
 
 func (ps *FliperSolver[T]) FirstError() error {
-	ctx, cancel := ps.initContext()
-	defer cancel()
-	ps.flipers.Run(ctx, ps.concurrency, 1)
-	return ps.flipers.FirstError(ctx)
+	if errs := ps.FirstNErrors(1); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
 }
 
 func (ps *FliperSolver[T]) FirstNErrors(n int) Errors {
 	ctx, cancel := ps.initContext()
 	defer cancel()
-	ps.flipers.Run(ctx, ps.concurrency, n)
-	return ps.flipers.FirstNErrors(ctx, n)
+	ctx, end := ps.traceBatch(ctx, n)
+	errs := ps.firstNErrors(ctx, n)
+	end(errs)
+	return errs
+}
+
+func (ps *FliperSolver[T]) firstNErrors(ctx context.Context, n int) Errors {
+	quit := make(chan struct{})
+	defer close(quit)
+
+	done := ps.run(ctx, n, quit)
+	errs := make(Errors, 0, n)
+	for {
+		select {
+		case i, ok := <-done:
+			if !ok {
+				if len(errs) == 0 {
+					return nil
+				}
+				return errs
+			}
+			if s := ps.settle(i); s.Err != nil {
+				errs = append(errs, &TaskError{Index: i, Err: s.Err})
+			}
+		case <-ctx.Done():
+			errs = append(errs, ctx.Err())
+			return errs
+		}
+		if n > 0 && n == len(errs) {
+			return errs
+		}
+	}
 }
 
 func (ps *FliperSolver[T]) ErrorsAll() Errors {
@@ -72,7 +485,19 @@ func (ps *FliperSolver[T]) ErrorsAll() Errors {
 }
 
 func (ps *FliperSolver[T]) Results() Results[T] {
-	return ps.flipers.Results()
+	pp, _ := ps.snapshot()
+	return pp.Results()
+}
+
+// Settled возвращает итоги всех задач на текущий момент: статус, значение, ошибку,
+// время начала и окончания и количество попыток.
+func (ps *FliperSolver[T]) Settled() []Settlement[T] {
+	pp, tasks := ps.snapshot()
+	res := pp.Settled()
+	for i := range res {
+		tasks[i].stats(&res[i])
+	}
+	return res
 }
 
 func (ps *FliperSolver[T]) Resolve() ([]T, error) {
@@ -81,5 +506,6 @@ func (ps *FliperSolver[T]) Resolve() ([]T, error) {
 }
 
 func (ps *FliperSolver[T]) Tail() <-chan struct{} {
-	return ps.flipers.Tail()
+	pp, _ := ps.snapshot()
+	return pp.Tail()
 }
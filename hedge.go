@@ -0,0 +1,121 @@
+package pipers
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// hedgeMinSamples — сколько задач должно завершиться, прежде чем
+// задержка Hedge начнёт вычисляться по перцентилю.
+const hedgeMinSamples = 5
+
+// hedgeMaxSamples — сколько последних длительностей учитывается в перцентиле.
+const hedgeMaxSamples = 256
+
+// Hedge описывает подстраховочный запуск задачи: если попытка не завершилась
+// за Delay, запускается её дубликат, и побеждает тот, кто первым завершится успешно.
+// Проигравший получает отменённый контекст.
+// Если Percentile > 0, задержка равна этому перцентилю (от 0 до 100) длительности
+// уже завершившихся задач решателя, а Delay используется, пока их меньше пяти.
+type Hedge struct {
+	Delay      time.Duration
+	Percentile float64
+}
+
+// hedges хранит общую для решателя статистику подстраховочных запусков.
+type hedges struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+	fired   int
+}
+
+func (hs *hedges) observe(d time.Duration) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	if len(hs.samples) < hedgeMaxSamples {
+		hs.samples = append(hs.samples, d)
+		return
+	}
+	hs.samples[hs.next] = d
+	hs.next = (hs.next + 1) % hedgeMaxSamples
+}
+
+func (hs *hedges) fire() {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	hs.fired++
+}
+
+func (hs *hedges) count() int {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	return hs.fired
+}
+
+// delay возвращает задержку перед запуском дубликата по настройкам h.
+func (hs *hedges) delay(h *Hedge) time.Duration {
+	if h.Percentile <= 0 {
+		return h.Delay
+	}
+	hs.mu.Lock()
+	samples := append([]time.Duration(nil), hs.samples...)
+	hs.mu.Unlock()
+	if len(samples) < hedgeMinSamples {
+		return h.Delay
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	k := int(h.Percentile / 100 * float64(len(samples)))
+	if k >= len(samples) {
+		k = len(samples) - 1
+	}
+	return samples[k]
+}
+
+// runHedge выполняет попытку задачи через call и, если она не завершилась за задержку h,
+// запускает её дубликат тоже через call, чтобы он, например, дождался токена RateLimit.
+// Возвращается первый успешный результат; ошибка возвращается, только когда
+// не осталось работающих попыток.
+func (t *task[T]) runHedge(ctx context.Context, h *Hedge, hs *hedges, call func(ctx context.Context) (T, error)) (T, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		res T
+		err error
+	}
+	ch := make(chan result, 2)
+	launch := func() {
+		go func() {
+			res, err := call(ctx)
+			ch <- result{res, err}
+		}()
+	}
+
+	start := time.Now()
+	launch()
+	running := 1
+	timer := time.NewTimer(hs.delay(h))
+	defer timer.Stop()
+
+	for {
+		select {
+		case r := <-ch:
+			running--
+			if r.err == nil {
+				hs.observe(time.Since(start))
+			}
+			if r.err == nil || running == 0 {
+				return r.res, r.err
+			}
+		case <-timer.C:
+			if running == 1 {
+				hs.fire()
+				launch()
+				running++
+			}
+		}
+	}
+}
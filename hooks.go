@@ -0,0 +1,75 @@
+package pipers
+
+import "time"
+
+// TaskEvent описывает событие жизненного цикла задачи (см. Hooks).
+// Для OnStart Attempt равен 1, а Duration и Err пусты. Для завершающих событий
+// Attempt — число сделанных попыток (0, если итог взят из кеша),
+// Duration — полное время выполнения задачи, Err — её ошибка.
+type TaskEvent struct {
+	Index    int
+	Attempt  int
+	Duration time.Duration
+	Err      error
+}
+
+// Hooks — обработчики событий жизненного цикла задач, например для логирования,
+// метрик или трассировки. Любой из обработчиков может быть nil.
+// OnStart вызывается при запуске задачи, затем ровно один из OnSuccess, OnError
+// и OnCancel — при её завершении. Задачи, которые так и не были запущены, событий не порождают.
+// Обработчики вызываются из горутины задачи и должны быть безопасны для одновременного вызова.
+type Hooks struct {
+	OnStart   func(TaskEvent)
+	OnSuccess func(TaskEvent)
+	OnError   func(TaskEvent)
+	OnCancel  func(TaskEvent)
+}
+
+// Hooks добавляет обработчики событий задач. Можно вызывать несколько раз:
+// обработчики вызываются в порядке добавления. К Flight, добавленным через Add,
+// обработчики не применяются.
+func (ps *FliperSolver[T]) Hooks(h Hooks) *FliperSolver[T] {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.hooks = append(ps.hooks, h)
+	return ps
+}
+
+// observe выполняет задачу, сообщая о её запуске и завершении обработчикам Hooks и Metrics.
+func (ps *FliperSolver[T]) observe(t *task[T], run func() (T, error)) (T, error) {
+	ps.mu.Lock()
+	hooks, metrics := ps.hooks, ps.metrics
+	ps.mu.Unlock()
+	if len(hooks) == 0 && metrics == nil {
+		return run()
+	}
+
+	if metrics != nil {
+		metrics.TaskStarted(time.Since(t.queued))
+	}
+	for _, h := range hooks {
+		if h.OnStart != nil {
+			h.OnStart(TaskEvent{Index: t.index, Attempt: 1})
+		}
+	}
+	start := time.Now()
+	res, err := run()
+	e := TaskEvent{Index: t.index, Attempt: t.attempted(), Duration: time.Since(start), Err: err}
+	st := status(err)
+	if metrics != nil {
+		metrics.TaskFinished(e.Duration, st)
+	}
+	for _, h := range hooks {
+		on := h.OnSuccess
+		switch st {
+		case StatusCanceled:
+			on = h.OnCancel
+		case StatusFailed:
+			on = h.OnError
+		}
+		if on != nil {
+			on(e)
+		}
+	}
+	return res, err
+}
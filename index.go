@@ -2,10 +2,6 @@ package pipers
 
 import "context"
 
-func printDebug(template string, rest ...interface{}) {
-	//fmt.Printf("pipers:  [ %v ]    "+template+"\n", append([]interface{}{time.Now().String()[0:25]}, rest...)...)
-}
-
 func FromFuncs[T any](funcs ...func() (T, error)) *FliperSolver[T] {
 	ps := FliperSolver[T]{
 		flipers: make(Flipers[T], 0, len(funcs)),
@@ -48,6 +44,54 @@ func FromArgsCtx[T any, A any](args []A, f func(context.Context, int, A) (T, err
 	return FromFuncsCtx(funcs...)
 }
 
+// FromArgsDedup — вариант FromArgs, в котором одинаковые аргументы обрабатываются
+// одним вызовом f: его результат получают все индексы с этим аргументом (см. WithKey).
+// f получает индекс первого вхождения аргумента.
+func FromArgsDedup[T any, A comparable](args []A, f func(int, A) (T, error)) *FliperSolver[T] {
+	ps := FliperSolver[T]{
+		flipers: make(Flipers[T], 0, len(args)),
+	}
+	for i, v := range args {
+		i, v := i, v
+		ps.AddFunc(func() (T, error) {
+			return f(i, v)
+		}, WithKey(v))
+	}
+	return &ps
+}
+
+// FromArgsWeighted — вариант FromArgs, в котором вес каждой задачи
+// для ограничения WeightLimit вычисляет weight по её аргументу.
+func FromArgsWeighted[T any, A any](args []A, weight func(A) int64, f func(int, A) (T, error)) *FliperSolver[T] {
+	ps := FliperSolver[T]{
+		flipers: make(Flipers[T], 0, len(args)),
+	}
+	for i, v := range args {
+		i, v := i, v
+		ps.AddFuncWeighted(weight(v), func() (T, error) {
+			return f(i, v)
+		})
+	}
+	return &ps
+}
+
+// FromChan создаёт решатель, задачи которого появляются по мере чтения из in:
+// для каждого значения вызывается f с его порядковым номером.
+// Значения вычитываются не быстрее, чем освобождаются слоты Concurrency,
+// а после достижения лимита ошибок или завершения контекста чтение прекращается.
+// Решатель завершает работу, когда in закрыт и все задачи выполнены.
+func FromChan[T any, A any](in <-chan A, f func(int, A) (T, error)) *FliperSolver[T] {
+	return FromChanCtx(in, func(_ context.Context, i int, a A) (T, error) {
+		return f(i, a)
+	})
+}
+
+func FromChanCtx[T any, A any](in <-chan A, f func(context.Context, int, A) (T, error)) *FliperSolver[T] {
+	ps := FliperSolver[T]{}
+	ps.source = chanSource(&ps, in, f)
+	return &ps
+}
+
 func Ref[T any](p *T, f func() (T, error)) func() (interface{}, error) {
 	return func() (interface{}, error) {
 		res, err := f()
@@ -0,0 +1,46 @@
+//go:build go1.23
+
+package pipers
+
+import (
+	"context"
+	"iter"
+)
+
+// FromSeq — вариант FromChan для итератора: значения запрашиваются у seq
+// по мере освобождения слотов Concurrency.
+func FromSeq[T any, A any](seq iter.Seq[A], f func(int, A) (T, error)) *FliperSolver[T] {
+	return FromSeqCtx(seq, func(_ context.Context, i int, a A) (T, error) {
+		return f(i, a)
+	})
+}
+
+func FromSeqCtx[T any, A any](seq iter.Seq[A], f func(context.Context, int, A) (T, error)) *FliperSolver[T] {
+	ps := FliperSolver[T]{}
+	var pull func() (A, bool)
+	var stop func()
+	ps.source = &source{
+		next: func(ctx context.Context) bool {
+			if ctx.Err() != nil {
+				return false
+			}
+			if pull == nil {
+				pull, stop = iter.Pull(seq)
+			}
+			a, ok := pull()
+			if !ok {
+				return false
+			}
+			ps.add(func(ctx context.Context, i int) (T, error) {
+				return f(ctx, i, a)
+			}, nil)
+			return true
+		},
+		stop: func() {
+			if stop != nil {
+				stop()
+			}
+		},
+	}
+	return &ps
+}
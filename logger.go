@@ -0,0 +1,26 @@
+package pipers
+
+// logger — журнал событий планировщика. args — пары ключ-значение, как в log/slog
+// (см. FliperSolver.Logger, доступный начиная с Go 1.21).
+type logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+}
+
+// nopLogger отбрасывает все события; используется, пока журнал не задан.
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...any) {}
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Warn(string, ...any)  {}
+
+// log возвращает журнал решателя.
+func (ps *FliperSolver[T]) log() logger {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if ps.logger == nil {
+		return nopLogger{}
+	}
+	return ps.logger
+}
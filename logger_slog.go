@@ -0,0 +1,19 @@
+//go:build go1.21
+
+package pipers
+
+import "log/slog"
+
+// Logger задаёт журнал, в который решатель пишет события планировщика:
+// постановку задачи в очередь, запуск и завершение (Debug), ошибку задачи
+// и достижение лимита ошибок (Warn), завершение контекста (Info) и завершение
+// всех запущенных задач (Debug). nil отключает журнал.
+func (ps *FliperSolver[T]) Logger(l *slog.Logger) *FliperSolver[T] {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.logger = nil
+	if l != nil {
+		ps.logger = l
+	}
+	return ps
+}
@@ -0,0 +1,288 @@
+package pipers
+
+import (
+	"bufio"
+	"expvar"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metrics получает события задач решателя для сбора метрик (см. FliperSolver.Metrics).
+// TaskStarted вызывается при запуске задачи с временем, которое она провела в очереди,
+// TaskFinished — при её завершении с временем выполнения и итоговым статусом
+// (StatusSucceeded, StatusFailed или StatusCanceled).
+// Методы вызываются из горутин задач и должны быть безопасны для одновременного вызова.
+type Metrics interface {
+	TaskStarted(wait time.Duration)
+	TaskFinished(d time.Duration, status Status)
+}
+
+// Metrics подключает к решателю сборщик метрик m, например Collector.
+// К Flight, добавленным через Add, метрики не применяются.
+func (ps *FliperSolver[T]) Metrics(m Metrics) *FliperSolver[T] {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.metrics = m
+	return ps
+}
+
+// DefaultBuckets — верхние границы корзин гистограмм Collector в секундах.
+var DefaultBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Histogram — снимок гистограммы длительностей. Counts[k] — число наблюдений,
+// не превышающих Buckets[k] секунд; Count и Sum — число и сумма (в секундах) всех наблюдений.
+type Histogram struct {
+	Buckets []float64
+	Counts  []uint64
+	Count   uint64
+	Sum     float64
+}
+
+func newHistogram() Histogram {
+	return Histogram{Buckets: DefaultBuckets, Counts: make([]uint64, len(DefaultBuckets))}
+}
+
+func (h *Histogram) observe(d time.Duration) {
+	s := d.Seconds()
+	for k, le := range h.Buckets {
+		if s <= le {
+			h.Counts[k]++
+		}
+	}
+	h.Count++
+	h.Sum += s
+}
+
+func (h *Histogram) add(o Histogram) {
+	for k := range h.Counts {
+		h.Counts[k] += o.Counts[k]
+	}
+	h.Count += o.Count
+	h.Sum += o.Sum
+}
+
+func (h Histogram) clone() Histogram {
+	h.Counts = append([]uint64(nil), h.Counts...)
+	return h
+}
+
+// MetricsSnapshot — значения метрик Collector на момент вызова Snapshot или Aggregate.
+type MetricsSnapshot struct {
+	Name      string
+	Started   uint64
+	Succeeded uint64
+	Failed    uint64
+	Canceled  uint64
+	InFlight  int64
+	QueueWait Histogram
+	Duration  Histogram
+}
+
+func (s *MetricsSnapshot) add(o MetricsSnapshot) {
+	s.Started += o.Started
+	s.Succeeded += o.Succeeded
+	s.Failed += o.Failed
+	s.Canceled += o.Canceled
+	s.InFlight += o.InFlight
+	s.QueueWait.add(o.QueueWait)
+	s.Duration.add(o.Duration)
+}
+
+// Collector — встроенная реализация Metrics: считает запущенные, успешные,
+// неудачные и отменённые задачи, число выполняемых задач и строит гистограммы
+// времени ожидания в очереди и времени выполнения.
+// Сборщики именованы и общие для всего процесса: решатели, подключённые к сборщику
+// с одним именем, учитываются вместе. Метрики всех сборщиков выгружаются
+// через WritePrometheus и PublishExpvar; Unregister убирает сборщик из выгрузки.
+type Collector struct {
+	mu sync.Mutex
+	s  MetricsSnapshot
+}
+
+var collectors = struct {
+	sync.Mutex
+	m map[string]*Collector
+}{m: make(map[string]*Collector)}
+
+// NewCollector возвращает сборщик с именем name, создавая его при первом обращении
+// (в том числе после Unregister).
+func NewCollector(name string) *Collector {
+	collectors.Lock()
+	defer collectors.Unlock()
+	if c, ok := collectors.m[name]; ok {
+		return c
+	}
+	c := &Collector{s: MetricsSnapshot{Name: name, QueueWait: newHistogram(), Duration: newHistogram()}}
+	collectors.m[name] = c
+	return c
+}
+
+func (c *Collector) Name() string {
+	return c.s.Name
+}
+
+// Unregister убирает сборщик из общего реестра: его метрики больше не выгружаются
+// и не входят в Aggregate, а NewCollector с тем же именем создаст новый сборщик.
+// Подключённые к нему решатели продолжают считать в него.
+func (c *Collector) Unregister() {
+	collectors.Lock()
+	defer collectors.Unlock()
+	if collectors.m[c.s.Name] == c {
+		delete(collectors.m, c.s.Name)
+	}
+}
+
+func (c *Collector) TaskStarted(wait time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.s.Started++
+	c.s.InFlight++
+	c.s.QueueWait.observe(wait)
+}
+
+func (c *Collector) TaskFinished(d time.Duration, status Status) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.s.InFlight--
+	c.s.Duration.observe(d)
+	switch status {
+	case StatusSucceeded:
+		c.s.Succeeded++
+	case StatusFailed:
+		c.s.Failed++
+	case StatusCanceled:
+		c.s.Canceled++
+	}
+}
+
+// Snapshot возвращает текущие значения метрик сборщика.
+func (c *Collector) Snapshot() MetricsSnapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.s
+	s.QueueWait = s.QueueWait.clone()
+	s.Duration = s.Duration.clone()
+	return s
+}
+
+// snapshots возвращает снимки всех сборщиков, упорядоченные по имени.
+func snapshots() []MetricsSnapshot {
+	collectors.Lock()
+	list := make([]*Collector, 0, len(collectors.m))
+	for _, c := range collectors.m {
+		list = append(list, c)
+	}
+	collectors.Unlock()
+
+	res := make([]MetricsSnapshot, len(list))
+	for k, c := range list {
+		res[k] = c.Snapshot()
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res
+}
+
+// Aggregate возвращает сумму метрик всех сборщиков процесса; Name у неё пустое.
+func Aggregate() MetricsSnapshot {
+	total := MetricsSnapshot{QueueWait: newHistogram(), Duration: newHistogram()}
+	for _, s := range snapshots() {
+		total.add(s)
+	}
+	return total
+}
+
+var published = struct {
+	sync.Mutex
+	names map[string]bool
+}{names: make(map[string]bool)}
+
+// PublishExpvar публикует метрики всех сборщиков в expvar под именем name:
+// объект с метриками каждого сборщика по его имени и их суммой под ключом "total".
+// Повторные вызовы с тем же именем ничего не делают; как и expvar.Publish,
+// паникует, если имя уже занято другой переменной.
+func PublishExpvar(name string) {
+	published.Lock()
+	defer published.Unlock()
+	if published.names[name] {
+		return
+	}
+	published.names[name] = true
+	expvar.Publish(name, expvar.Func(func() any {
+		res := make(map[string]MetricsSnapshot)
+		for _, s := range snapshots() {
+			res[s.Name] = s
+		}
+		res["total"] = Aggregate()
+		return res
+	}))
+}
+
+// WritePrometheus записывает метрики всех сборщиков в текстовом формате Prometheus
+// с меткой solver, равной имени сборщика. Сумму по всем решателям Prometheus
+// вычисляет сам, например sum(pipers_tasks_started_total).
+func WritePrometheus(w io.Writer) error {
+	list := snapshots()
+	bw := bufio.NewWriter(w)
+
+	counter := func(name, help string, value func(s MetricsSnapshot) uint64) {
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+		for _, s := range list {
+			fmt.Fprintf(bw, "%s{solver=%s} %d\n", name, quote(s.Name), value(s))
+		}
+	}
+	histogram := func(name, help string, value func(s MetricsSnapshot) Histogram) {
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+		for _, s := range list {
+			h, solver := value(s), quote(s.Name)
+			for k, le := range h.Buckets {
+				fmt.Fprintf(bw, "%s_bucket{solver=%s,le=\"%g\"} %d\n", name, solver, le, h.Counts[k])
+			}
+			fmt.Fprintf(bw, "%s_bucket{solver=%s,le=\"+Inf\"} %d\n", name, solver, h.Count)
+			fmt.Fprintf(bw, "%s_sum{solver=%s} %g\n", name, solver, h.Sum)
+			fmt.Fprintf(bw, "%s_count{solver=%s} %d\n", name, solver, h.Count)
+		}
+	}
+
+	counter("pipers_tasks_started_total", "Tasks started.",
+		func(s MetricsSnapshot) uint64 { return s.Started })
+	counter("pipers_tasks_succeeded_total", "Tasks finished without error.",
+		func(s MetricsSnapshot) uint64 { return s.Succeeded })
+	counter("pipers_tasks_failed_total", "Tasks finished with error.",
+		func(s MetricsSnapshot) uint64 { return s.Failed })
+	counter("pipers_tasks_canceled_total", "Tasks canceled.",
+		func(s MetricsSnapshot) uint64 { return s.Canceled })
+
+	fmt.Fprintf(bw, "# HELP pipers_tasks_in_flight Tasks running now.\n# TYPE pipers_tasks_in_flight gauge\n")
+	for _, s := range list {
+		fmt.Fprintf(bw, "pipers_tasks_in_flight{solver=%s} %d\n", quote(s.Name), s.InFlight)
+	}
+
+	histogram("pipers_task_queue_wait_seconds", "Time tasks spent waiting to start.",
+		func(s MetricsSnapshot) Histogram { return s.QueueWait })
+	histogram("pipers_task_duration_seconds", "Time tasks spent running.",
+		func(s MetricsSnapshot) Histogram { return s.Duration })
+
+	return bw.Flush()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// quote экранирует значение метки по правилам текстового формата Prometheus.
+func quote(v string) string {
+	return `"` + labelEscaper.Replace(v) + `"`
+}
+
+// status возвращает статус завершившейся задачи по её ошибке.
+func status(err error) Status {
+	switch {
+	case err != nil && isCanceled(err):
+		return StatusCanceled
+	case err != nil:
+		return StatusFailed
+	}
+	return StatusSucceeded
+}
@@ -0,0 +1,70 @@
+// Package otelpipers подключает трассировку pipers к OpenTelemetry.
+// Вынесен в отдельный модуль, чтобы сам pipers оставался без зависимостей.
+package otelpipers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kozhurkin/pipers"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracer возвращает pipers.Tracer, создающий span через t.
+// Длительности записываются строками вида "1.5ms", значения неизвестных типов — через fmt.Sprint.
+func Tracer(t trace.Tracer) pipers.Tracer {
+	return tracer{t}
+}
+
+type tracer struct {
+	t trace.Tracer
+}
+
+func (tr tracer) Start(ctx context.Context, name string, attrs ...any) (context.Context, pipers.Span) {
+	ctx, s := tr.t.Start(ctx, name, trace.WithAttributes(attributes(attrs)...))
+	return ctx, span{s}
+}
+
+type span struct {
+	s trace.Span
+}
+
+func (sp span) AddEvent(name string, attrs ...any) {
+	sp.s.AddEvent(name, trace.WithAttributes(attributes(attrs)...))
+}
+
+func (sp span) End(err error) {
+	if err != nil {
+		sp.s.RecordError(err)
+		sp.s.SetStatus(codes.Error, err.Error())
+	}
+	sp.s.End()
+}
+
+// attributes переводит пары ключ-значение в атрибуты OpenTelemetry.
+func attributes(kv []any) []attribute.KeyValue {
+	res := make([]attribute.KeyValue, 0, len(kv)/2)
+	for k := 0; k+1 < len(kv); k += 2 {
+		key := fmt.Sprint(kv[k])
+		switch v := kv[k+1].(type) {
+		case string:
+			res = append(res, attribute.String(key, v))
+		case int:
+			res = append(res, attribute.Int(key, v))
+		case int64:
+			res = append(res, attribute.Int64(key, v))
+		case float64:
+			res = append(res, attribute.Float64(key, v))
+		case bool:
+			res = append(res, attribute.Bool(key, v))
+		case time.Duration:
+			res = append(res, attribute.String(key, v.String()))
+		default:
+			res = append(res, attribute.String(key, fmt.Sprint(v)))
+		}
+	}
+	return res
+}
@@ -0,0 +1,63 @@
+package otelpipers_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kozhurkin/pipers"
+	"github.com/kozhurkin/pipers/otelpipers"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracer(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	tracer := otelpipers.Tracer(provider.Tracer("pipers"))
+
+	errs := pipers.FromArgsCtx([]int{1, 2}, func(ctx context.Context, i int, v int) (int, error) {
+		if v == 2 {
+			return 0, errors.New("boom")
+		}
+		return v, nil
+	}).Tracer(tracer).ErrorsAll()
+	assert.Len(t, errs, 1)
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 3)
+
+	var batch tracetest.SpanStub
+	tasks := make(map[int64]tracetest.SpanStub)
+	for _, s := range spans {
+		switch s.Name {
+		case "pipers.batch":
+			batch = s
+		case "pipers.task":
+			for _, a := range s.Attributes {
+				if a.Key == "index" {
+					tasks[a.Value.AsInt64()] = s
+				}
+			}
+		}
+	}
+
+	assert.Equal(t, codes.Error, batch.Status.Code)
+	assert.Contains(t, batch.Attributes, attribute.Int("tasks", 2))
+	assert.Len(t, tasks, 2)
+	for _, s := range tasks {
+		assert.Equal(t, batch.SpanContext.SpanID(), s.Parent.SpanID())
+		assert.Equal(t, batch.SpanContext.TraceID(), s.SpanContext.TraceID())
+		var events []string
+		for _, e := range s.Events {
+			events = append(events, e.Name)
+		}
+		assert.Equal(t, []string{"queued", "finished"}, events[:2])
+	}
+	assert.Equal(t, codes.Unset, tasks[0].Status.Code)
+	assert.Equal(t, codes.Error, tasks[1].Status.Code)
+	assert.Equal(t, "boom", tasks[1].Status.Description)
+}
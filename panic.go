@@ -0,0 +1,42 @@
+package pipers
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError возвращается задачей, обработчик которой запаниковал.
+// Value — значение, переданное в panic, Stack — стек горутины в момент паники.
+type PanicError struct {
+	Index int
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap возвращает значение паники, если оно само является ошибкой.
+func (e *PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// call вызывает функцию задачи, превращая её панику в PanicError.
+// Без этого паника в обработчике роняла бы весь процесс из горутины Flight.
+// Готовые Flight (FliperSolver.Add, Flipers.Run) выполняются как есть: их функцию
+// обернуть нельзя, поэтому их паника по-прежнему роняет процесс.
+func (t *task[T]) call(ctx context.Context) (res T, err error) {
+	t.attempt()
+	defer func() {
+		if v := recover(); v != nil {
+			var zero T
+			res, err = zero, &PanicError{Index: t.index, Value: v, Stack: debug.Stack()}
+		}
+	}()
+	return t.fn(ctx)
+}
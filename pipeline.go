@@ -0,0 +1,215 @@
+package pipers
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// StageError связывает ошибку конвейера с номером стадии (с 1) и индексом
+// входного элемента, на котором она возникла.
+type StageError struct {
+	Stage int
+	Index int
+	Err   error
+}
+
+func (e *StageError) Error() string {
+	return fmt.Sprintf("stage %d, item %d: %v", e.Stage, e.Index, e.Err)
+}
+
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// Stage — стадия конвейера, преобразующая элементы типа A в элементы типа B.
+type Stage[A, B any] struct {
+	fn          func(ctx context.Context, a A) (B, error)
+	concurrency int
+	buffer      int
+}
+
+func NewStage[A, B any](f func(ctx context.Context, a A) (B, error)) *Stage[A, B] {
+	return &Stage[A, B]{fn: f, concurrency: 1}
+}
+
+// call вызывает функцию стадии для элемента с индексом index, превращая её панику
+// в PanicError, как это делается для задач решателя.
+func (s *Stage[A, B]) call(ctx context.Context, index int, a A) (b B, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			var zero B
+			b, err = zero, &PanicError{Index: index, Value: v, Stack: debug.Stack()}
+		}
+	}()
+	return s.fn(ctx, a)
+}
+
+// Concurrency задаёт число элементов, обрабатываемых стадией одновременно (по умолчанию 1).
+func (s *Stage[A, B]) Concurrency(concurrency int) *Stage[A, B] {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	s.concurrency = concurrency
+	return s
+}
+
+// Buffer задаёт размер буфера между стадией и следующей за ней (по умолчанию равен Concurrency).
+// Когда буфер заполнен, стадия ждёт, пока следующая стадия заберёт результат.
+func (s *Stage[A, B]) Buffer(size int) *Stage[A, B] {
+	s.buffer = size
+	return s
+}
+
+type item[T any] struct {
+	index int
+	value T
+}
+
+// Pipeline — конвейер из последовательных стадий (см. Pipe и Then).
+// Все стадии работают одновременно: элемент передаётся следующей стадии,
+// как только предыдущая его обработала. Первая ошибка любой стадии
+// останавливает весь конвейер.
+type Pipeline[T any] struct {
+	stages  int
+	start   func(ctx context.Context, fail func(error)) <-chan item[T]
+	context context.Context
+	ordered bool
+}
+
+// Pipe создаёт конвейер, на вход которого поступают элементы in.
+func Pipe[A any](in []A) *Pipeline[A] {
+	return &Pipeline[A]{
+		start: func(ctx context.Context, _ func(error)) <-chan item[A] {
+			out := make(chan item[A])
+			go func() {
+				defer close(out)
+				for i, a := range in {
+					select {
+					case out <- item[A]{i, a}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+			return out
+		},
+	}
+}
+
+// Then добавляет в конец конвейера p стадию s.
+// Настройки Context и Ordered задаются у итогового конвейера.
+func Then[A, B any](p *Pipeline[A], s *Stage[A, B]) *Pipeline[B] {
+	stage := p.stages + 1
+	return &Pipeline[B]{
+		stages: stage,
+		start: func(ctx context.Context, fail func(error)) <-chan item[B] {
+			in := p.start(ctx, fail)
+			buffer := s.buffer
+			if buffer <= 0 {
+				buffer = s.concurrency
+			}
+			out := make(chan item[B], buffer)
+			var wg sync.WaitGroup
+			for w := 0; w < s.concurrency; w++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for it := range in {
+						b, err := s.call(ctx, it.index, it.value)
+						if err != nil {
+							fail(&StageError{Stage: stage, Index: it.index, Err: err})
+							return
+						}
+						select {
+						case out <- item[B]{it.index, b}:
+						case <-ctx.Done():
+							return
+						}
+					}
+				}()
+			}
+			go func() {
+				wg.Wait()
+				close(out)
+			}()
+			return out
+		},
+	}
+}
+
+func (p *Pipeline[T]) Context(ctx context.Context) *Pipeline[T] {
+	p.context = ctx
+	return p
+}
+
+// Ordered включает выдачу результатов в порядке входных элементов.
+// По умолчанию результаты выдаются в порядке готовности. Результаты, опередившие
+// ещё не готовые предыдущие, ждут их в памяти.
+func (p *Pipeline[T]) Ordered() *Pipeline[T] {
+	p.ordered = true
+	return p
+}
+
+// Stream запускает конвейер и возвращает канал результатов последней стадии
+// и функцию, возвращающую первую ошибку конвейера. Канал нужно вычитать до конца:
+// он закрывается, когда конвейер завершён, после чего функция ошибки не блокируется.
+func (p *Pipeline[T]) Stream() (<-chan T, func() error) {
+	parent := p.context
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+
+	var once sync.Once
+	var failure error
+	fail := func(err error) {
+		once.Do(func() {
+			failure = err
+			cancel()
+		})
+	}
+
+	in := p.start(ctx, fail)
+	out := make(chan T)
+	done := make(chan struct{})
+	go func() {
+		defer func() {
+			fail(parent.Err())
+			cancel()
+			close(out)
+			close(done)
+		}()
+		pending := make(map[int]T)
+		var next int
+		for it := range in {
+			if !p.ordered {
+				out <- it.value
+				continue
+			}
+			pending[it.index] = it.value
+			for v, ok := pending[next]; ok; v, ok = pending[next] {
+				out <- v
+				delete(pending, next)
+				next++
+			}
+		}
+	}()
+
+	return out, func() error {
+		<-done
+		return failure
+	}
+}
+
+// Resolve выполняет конвейер и возвращает результаты последней стадии
+// вместе с первой ошибкой. При ошибке результаты неполные.
+func (p *Pipeline[T]) Resolve() ([]T, error) {
+	out, wait := p.Stream()
+	var res []T
+	for v := range out {
+		res = append(res, v)
+	}
+	return res, wait()
+}
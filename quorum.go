@@ -0,0 +1,66 @@
+package pipers
+
+import "fmt"
+
+// Quorum запускает задачи и возвращает результаты первых k задач, завершившихся
+// без ошибки, по их индексам. После этого контекст решателя отменяется,
+// поэтому остальные задачи, добавленные через AddFuncCtx, получают отменённый контекст.
+// Как только ошибок становится больше N-k и кворум уже недостижим, выполнение
+// прекращается так же, а возвращается AllFailedError с ошибками задач.
+// Для решателей с источником неизвестной длины (см. FromChan) недостижимость
+// кворума выясняется только после исчерпания источника.
+func (ps *FliperSolver[T]) Quorum(k int) (map[int]T, error) {
+	res := make(map[int]T, k)
+	if k <= 0 {
+		return res, nil
+	}
+
+	ps.mu.Lock()
+	total := len(ps.flipers)
+	if ps.source != nil {
+		total = -1
+	}
+	ps.mu.Unlock()
+	switch {
+	case total == 0:
+		return nil, ErrNoTasks
+	case total > 0 && k > total:
+		return nil, fmt.Errorf("pipers: quorum %d of %d tasks is unreachable", k, total)
+	}
+
+	ctx, cancel := ps.initContext()
+	defer cancel()
+	quit := make(chan struct{})
+	defer close(quit)
+
+	var errs Errors
+	done := ps.run(ctx, 0, quit)
+	for {
+		select {
+		case i, ok := <-done:
+			if !ok {
+				switch {
+				case len(errs) > 0:
+					return nil, &AllFailedError{Errs: errs}
+				case len(res) == 0:
+					return nil, ErrNoTasks
+				}
+				// источник исчерпан раньше, чем набралось k успешных задач
+				return nil, fmt.Errorf("pipers: quorum %d is unreachable: only %d tasks succeeded", k, len(res))
+			}
+			s := ps.settle(i)
+			if s.Err == nil {
+				if res[i] = s.Value; len(res) == k {
+					return res, nil
+				}
+				continue
+			}
+			errs = append(errs, &TaskError{Index: i, Err: s.Err})
+			if total > 0 && len(errs) > total-k {
+				return nil, &AllFailedError{Errs: errs}
+			}
+		case <-ctx.Done():
+			return nil, append(errs, ctx.Err()).Join()
+		}
+	}
+}
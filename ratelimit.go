@@ -0,0 +1,111 @@
+package pipers
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter ограничивает частоту запуска задач по алгоритму token bucket:
+// в секунду добавляется rps токенов, в запасе хранится не больше burst.
+// Один RateLimiter можно подключить к нескольким решателям (см. FliperSolver.RateLimiter),
+// тогда ограничение действует на них суммарно.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter создаёт RateLimiter на rps запусков в секунду с запасом burst.
+// Если rps <= 0, ограничение не действует; burst меньше 1 считается равным 1.
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// reserve забирает токен и возвращает, сколько нужно подождать до его появления.
+func (rl *RateLimiter) reserve() time.Duration {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if rl.rate <= 0 {
+		return 0
+	}
+	now := time.Now()
+	rl.tokens += now.Sub(rl.last).Seconds() * rl.rate
+	if rl.tokens > rl.burst {
+		rl.tokens = rl.burst
+	}
+	rl.last = now
+	rl.tokens--
+	if rl.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-rl.tokens / rl.rate * float64(time.Second))
+}
+
+// cancel возвращает токен, который так и не был использован.
+func (rl *RateLimiter) cancel() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.tokens++
+}
+
+// Wait блокируется до появления токена либо возвращает ошибку контекста.
+func (rl *RateLimiter) Wait(ctx context.Context) error {
+	d := rl.reserve()
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		rl.cancel()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (rl *RateLimiter) acquire(ctx context.Context, _ int) error {
+	return rl.Wait(ctx)
+}
+
+func (rl *RateLimiter) release(int, time.Duration, error) {}
+
+// admission — gate планировщика для RateLimiter решателя: токен, полученный
+// при запуске задачи, оплачивает её первую попытку (см. task.pay).
+// Задаче, итог которой будет взят из кеша решателя, токен не нужен.
+type admission[T any] struct {
+	rl *RateLimiter
+	ps *FliperSolver[T]
+}
+
+func (a admission[T]) acquire(ctx context.Context, i int) error {
+	a.ps.mu.Lock()
+	t := a.ps.tasks[i]
+	c := a.ps.cache
+	a.ps.mu.Unlock()
+	if c != nil && t.key != nil {
+		// если запись успеет устареть до запуска, попытка дождётся токена сама (см. task.pay)
+		if _, ok, _ := c.lookup(t.key); ok {
+			return nil
+		}
+	}
+	if err := a.rl.Wait(ctx); err != nil {
+		return err
+	}
+	t.prepay()
+	return nil
+}
+
+func (a admission[T]) release(int, time.Duration, error) {}
@@ -0,0 +1,119 @@
+package pipers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff возвращает задержку перед повтором номер attempt (1 — первый повтор).
+type Backoff func(attempt int) time.Duration
+
+// ConstantBackoff возвращает Backoff с одинаковой задержкой перед каждым повтором.
+func ConstantBackoff(d time.Duration) Backoff {
+	return func(int) time.Duration {
+		return d
+	}
+}
+
+// ExponentialBackoff возвращает Backoff, удваивающий задержку с каждым повтором,
+// начиная с base. Если max > 0, задержка не превышает max.
+func ExponentialBackoff(base, max time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		d := base
+		for i := 1; i < attempt && d < math.MaxInt64/2; i++ {
+			d *= 2
+		}
+		if max > 0 && d > max {
+			return max
+		}
+		return d
+	}
+}
+
+// FullJitter оборачивает Backoff так, что фактическая задержка выбирается
+// случайно в диапазоне [0, b(attempt)). Это разносит повторы параллельных задач во времени.
+func FullJitter(b Backoff) Backoff {
+	return func(attempt int) time.Duration {
+		d := b(attempt)
+		if d <= 0 {
+			return 0
+		}
+		return time.Duration(rand.Int63n(int64(d)))
+	}
+}
+
+// RetryPolicy описывает правила повторного запуска задачи при ошибке.
+// MaxAttempts — общее число попыток, включая первую; значения <= 1 отключают повторы.
+// Backoff — задержка перед повтором (nil — без задержки).
+// Retryable решает, стоит ли повторять задачу после ошибки (nil — повторять при любой ошибке,
+// кроме PanicError).
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     Backoff
+	Retryable   func(error) bool
+}
+
+// RetryError возвращается задачей, выполнявшейся по RetryPolicy,
+// и хранит ошибки всех сделанных попыток в порядке их возникновения.
+// errors.Is/errors.As проверяют ошибку последней попытки.
+type RetryError struct {
+	Index    int
+	Attempts []error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%v (attempts: %d)", e.Unwrap(), len(e.Attempts))
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Attempts[len(e.Attempts)-1]
+}
+
+func (p *RetryPolicy) retryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	var pe *PanicError
+	return !errors.As(err, &pe)
+}
+
+// sleep ждёт задержку перед повтором номер attempt.
+// Возвращает false, если контекст завершился раньше.
+func (p *RetryPolicy) sleep(ctx context.Context, attempt int) bool {
+	var d time.Duration
+	if p.Backoff != nil {
+		d = p.Backoff(attempt)
+	}
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// runRetry выполняет попытку call до успеха, но не более p.MaxAttempts раз.
+// Повторы выполняются внутри того же Flight, поэтому задача удерживает
+// слот Concurrency на всё время своих попыток, включая паузы между ними.
+func (t *task[T]) runRetry(ctx context.Context, p *RetryPolicy, call func(context.Context) (T, error)) (T, error) {
+	var errs []error
+	for attempt := 1; ; attempt++ {
+		res, err := call(ctx)
+		if err == nil {
+			return res, nil
+		}
+		errs = append(errs, err)
+		if attempt >= p.MaxAttempts || !p.retryable(err) || !p.sleep(ctx, attempt) {
+			return res, &RetryError{Index: t.index, Attempts: errs}
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package pipers
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kozhurkin/singleflight/flight"
+)
+
+// gate ограничивает запуск задач планировщиком.
+// acquire блокируется, пока задачу i нельзя запустить, либо возвращает ошибку контекста;
+// release вызывается после завершения задачи, которую пропустил acquire.
+// Слот slots в schedule берётся до выбора задачи, поэтому там i может быть равен -1.
+type gate interface {
+	acquire(ctx context.Context, i int) error
+	release(i int, latency time.Duration, err error)
+}
+
+// admit проходит все gates по порядку. Если какой-то из них не пропустил задачу,
+// уже пройденные освобождаются.
+func admit(ctx context.Context, gates []gate, i int) error {
+	for k, g := range gates {
+		if err := g.acquire(ctx, i); err != nil {
+			for _, g := range gates[:k] {
+				g.release(i, 0, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// held откладывает освобождение gate до момента, когда wait вызовет переданную ей функцию.
+// Так задача, завершённая по таймауту, не освобождает слот, пока её обработчик
+// ещё работает в фоне (см. task.whenIdle).
+type held struct {
+	gate
+	wait func(i int, f func())
+}
+
+func (h held) release(i int, latency time.Duration, err error) {
+	h.wait(i, func() {
+		h.gate.release(i, latency, err)
+	})
+}
+
+// schedule запускает Flight, которые по очереди возвращает next, пока тот не вернёт false.
+// Следующий Flight запрашивается у next только после того, как освободился слот slots,
+// поэтому источник задач неизвестной длины не вычитывается наперёд, а next выбирает
+// задачу в момент, когда её действительно можно запустить (см. FliperSolver.Aging).
+// Затем Flight должен пройти все gates (ограничение веса, частоты и т.п.).
+// Запуски прекращаются после errlimit ошибок (0 — без ограничения) или при завершении контекста.
+// Для каждого запущенного Flight после его завершения вызывается done, если он задан.
+// Решения планировщика записываются в log.
+// Первый канал закрывается, когда запуски прекращены, второй — когда вдобавок
+// завершились все запущенные Flight.
+func schedule[T any](
+	ctx context.Context,
+	log logger,
+	slots gate,
+	gates []gate,
+	errlimit int,
+	next func(ctx context.Context) (int, *flight.Flight[T], bool),
+	done func(int),
+) (<-chan struct{}, <-chan struct{}) {
+	scheduled := make(chan struct{})
+	finished := make(chan struct{})
+	wg := sync.WaitGroup{}
+
+	parent := ctx
+	go func() {
+		defer func() {
+			if err := parent.Err(); err != nil {
+				log.Info("pipers: context canceled", "err", err)
+			}
+			log.Debug("pipers: scheduling stopped")
+			close(scheduled)
+			wg.Wait()
+			close(finished)
+		}()
+
+		var errorCount int32
+		var errorLimit int32 = int32(errlimit)
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		for {
+			if slots.acquire(ctx, -1) != nil {
+				return // context canceled
+			}
+			i, p, ok := next(ctx)
+			if !ok {
+				slots.release(-1, 0, context.Canceled)
+				return
+			}
+			if err := admit(ctx, gates, i); err != nil {
+				slots.release(i, 0, err)
+				return // context canceled
+			}
+			if err := ctx.Err(); err != nil {
+				// задачу могли пропустить одновременно с отменой
+				for _, g := range gates {
+					g.release(i, 0, err)
+				}
+				slots.release(i, 0, err)
+				return
+			}
+			start := time.Now()
+			p.RunAsync()
+			log.Debug("pipers: task started", "index", i)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := p.Wait()
+				latency := time.Since(start)
+				if err != nil {
+					log.Warn("pipers: task failed", "index", i, "duration", latency, "err", err)
+				} else {
+					log.Debug("pipers: task finished", "index", i, "duration", latency)
+				}
+				if err != nil && errorLimit > 0 && atomic.AddInt32(&errorCount, 1) == errorLimit {
+					log.Warn("pipers: error limit reached", "limit", errorLimit, "index", i)
+					cancel()
+				}
+				for _, g := range gates {
+					g.release(i, latency, err)
+				}
+				slots.release(i, latency, err)
+				if done != nil {
+					done(i)
+				}
+			}()
+		}
+	}()
+
+	return scheduled, finished
+}
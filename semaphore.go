@@ -0,0 +1,162 @@
+package pipers
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// semaphore ограничивает суммарный вес одновременно выполняемых задач
+// (для ограничения concurrency вес каждой задачи равен 1).
+// В отличие от буферизированного канала, лимит можно менять во время работы:
+// увеличение сразу пропускает ожидающих, а уменьшение лишь придерживает новые
+// запуски, пока занятый вес не опустится ниже лимита.
+// Задача тяжелее самого лимита пропускается, когда семафор полностью свободен.
+// Если подключён регулятор AIMD, лимит задаёт он, а SetLimit не действует;
+// занятый вес тогда учитывается и в регуляторе, общем для всех подключённых к нему решателей.
+type semaphore struct {
+	mu       sync.Mutex
+	limit    int64 // 0 — без ограничения
+	active   int64
+	wake     chan struct{}
+	adaptive *AIMD
+}
+
+func newSemaphore(limit int64) *semaphore {
+	return &semaphore{limit: limit, wake: make(chan struct{})}
+}
+
+// broadcast будит всех ожидающих в Acquire. Вызывается под s.mu.
+func (s *semaphore) broadcast() {
+	close(s.wake)
+	s.wake = make(chan struct{})
+}
+
+// Acquire занимает вес n, дожидаясь его освобождения, либо возвращает ошибку контекста.
+func (s *semaphore) Acquire(ctx context.Context, n int64) error {
+	for {
+		s.mu.Lock()
+		if s.admit(n) {
+			s.mu.Unlock()
+			return nil
+		}
+		wake := s.wake
+		var shared <-chan struct{} // nil-канал никогда не сработает
+		if s.adaptive != nil {
+			shared = s.adaptive.waiter()
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-wake:
+		case <-shared:
+		}
+	}
+}
+
+// TryAcquire занимает вес n, только если он свободен прямо сейчас.
+func (s *semaphore) TryAcquire(n int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.admit(n)
+}
+
+// admit занимает вес n, если он свободен. Вызывается под s.mu.
+func (s *semaphore) admit(n int64) bool {
+	if s.adaptive != nil {
+		if !s.adaptive.take(n) {
+			return false
+		}
+		s.active += n
+		return true
+	}
+	if limit := s.limit; limit <= 0 || s.active+n <= limit || s.active == 0 {
+		s.active += n
+		return true
+	}
+	return false
+}
+
+// capacity возвращает действующий лимит. Вызывается под s.mu.
+func (s *semaphore) capacity() int64 {
+	if s.adaptive != nil {
+		return int64(s.adaptive.Limit())
+	}
+	return s.limit
+}
+
+// Observe сообщает регулятору (если он подключён) о завершении задачи.
+func (s *semaphore) Observe(latency time.Duration, err error) {
+	s.mu.Lock()
+	adaptive := s.adaptive
+	s.mu.Unlock()
+	if adaptive != nil {
+		adaptive.observe(latency, err)
+	}
+}
+
+// SetAdaptive подключает регулятор AIMD; nil возвращает фиксированный лимит.
+func (s *semaphore) SetAdaptive(c *AIMD) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// уже занятый вес переносится в новый регулятор
+	if s.adaptive != nil {
+		s.adaptive.give(s.active)
+	}
+	if c != nil {
+		c.hold(s.active)
+	}
+	s.adaptive = c
+	s.broadcast()
+}
+
+// Release освобождает вес n, занятый Acquire.
+func (s *semaphore) Release(n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active -= n
+	if s.adaptive != nil {
+		s.adaptive.give(n)
+	}
+	s.broadcast()
+}
+
+// SetLimit меняет лимит; 0 снимает ограничение.
+func (s *semaphore) SetLimit(limit int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limit = limit
+	s.broadcast()
+}
+
+// Limit возвращает действующий лимит.
+func (s *semaphore) Limit() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.capacity()
+}
+
+func (s *semaphore) acquire(ctx context.Context, _ int) error {
+	return s.Acquire(ctx, 1)
+}
+
+func (s *semaphore) release(_ int, latency time.Duration, err error) {
+	s.Observe(latency, err)
+	s.Release(1)
+}
+
+// weights — ограничение по суммарному весу задач поверх semaphore.
+type weights struct {
+	sem *semaphore
+	of  func(i int) int64
+}
+
+func (w weights) acquire(ctx context.Context, i int) error {
+	return w.sem.Acquire(ctx, w.of(i))
+}
+
+func (w weights) release(i int, _ time.Duration, _ error) {
+	w.sem.Release(w.of(i))
+}
@@ -0,0 +1,102 @@
+package pipers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/kozhurkin/singleflight/flight"
+)
+
+// Status описывает состояние задачи на момент вызова Settled.
+type Status int
+
+const (
+	StatusNotStarted Status = iota // задача не запускалась
+	StatusRunning                  // задача выполняется
+	StatusSucceeded                // задача завершилась без ошибки
+	StatusFailed                   // задача завершилась с ошибкой
+	StatusCanceled                 // задача отменена или прервана завершением контекста
+	StatusSkipped                  // задача не запускалась, так как не выполнилась её зависимость (см. DAG)
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusNotStarted:
+		return "not-started"
+	case StatusRunning:
+		return "running"
+	case StatusSucceeded:
+		return "succeeded"
+	case StatusFailed:
+		return "failed"
+	case StatusCanceled:
+		return "canceled"
+	case StatusSkipped:
+		return "skipped"
+	}
+	return "unknown"
+}
+
+// Settlement — итог отдельной задачи: статус, значение, ошибка,
+// время начала и окончания выполнения и количество попыток.
+// В отличие от Results, позволяет отличить нулевой результат от незавершённой задачи.
+type Settlement[T any] struct {
+	Index    int
+	Status   Status
+	Value    T
+	Err      error
+	Start    time.Time
+	End      time.Time
+	Attempts int
+}
+
+// Duration возвращает время выполнения задачи либо 0, если задача ещё не завершилась.
+func (s Settlement[T]) Duration() time.Duration {
+	if s.Start.IsZero() || s.End.IsZero() {
+		return 0
+	}
+	return s.End.Sub(s.Start)
+}
+
+// isCanceled сообщает, вызвана ли ошибка отменой, а не сбоем самой задачи.
+func isCanceled(err error) bool {
+	return errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, flight.ErrCanceled)
+}
+
+// settle возвращает итог отдельного Flight на текущий момент.
+func settle[T any](i int, p *flight.Flight[T]) Settlement[T] {
+	s := Settlement[T]{Index: i}
+	select {
+	case <-p.Done():
+		s.Value, s.Err = p.Wait()
+		switch {
+		case p.Canceled() || isCanceled(s.Err):
+			s.Status = StatusCanceled
+		case s.Err != nil:
+			s.Status = StatusFailed
+		default:
+			s.Status = StatusSucceeded
+		}
+	default:
+		if p.Started() {
+			s.Status = StatusRunning
+		}
+	}
+	if p.Started() {
+		s.Attempts = 1
+	}
+	return s
+}
+
+// Settled возвращает итоги всех Flight на текущий момент в порядке их следования.
+// Время выполнения Flight не отслеживает, поэтому Start и End остаются нулевыми.
+func (pp Flipers[T]) Settled() []Settlement[T] {
+	res := make([]Settlement[T], len(pp))
+	for i, p := range pp {
+		res[i] = settle(i, p)
+	}
+	return res
+}
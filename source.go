@@ -0,0 +1,31 @@
+package pipers
+
+import "context"
+
+// source поставляет задачи решателю по мере поступления входных данных (см. FromChan).
+type source struct {
+	// next добавляет в решатель очередную задачу и возвращает false,
+	// если данные закончились или контекст завершён.
+	next func(ctx context.Context) bool
+	// stop освобождает источник, когда решатель перестал запрашивать задачи.
+	stop func()
+}
+
+func chanSource[T any, A any](ps *FliperSolver[T], in <-chan A, f func(context.Context, int, A) (T, error)) *source {
+	return &source{
+		next: func(ctx context.Context) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case a, ok := <-in:
+				if !ok {
+					return false
+				}
+				ps.add(func(ctx context.Context, i int) (T, error) {
+					return f(ctx, i, a)
+				}, nil)
+				return true
+			}
+		},
+	}
+}
@@ -0,0 +1,54 @@
+package pipers
+
+import (
+	"sync"
+)
+
+// Stream запускает задачи и возвращает канал их итогов в порядке завершения.
+// n ограничивает число ошибок так же, как в FirstNErrors: после n-й ошибки новые задачи
+// не запускаются, а контекст решателя отменяется (0 — без ограничения).
+// Задачи, которые так и не были запущены, в канал не попадают.
+// Канал закрывается, когда завершатся все запущенные задачи.
+// Для решателей с источником неизвестной длины (см. FromChan) канал нужно вычитывать до конца.
+func (ps *FliperSolver[T]) Stream(n int) <-chan Settlement[T] {
+	out, _ := ps.stream(n)
+	return out
+}
+
+// stream реализует Stream и дополнительно возвращает функцию остановки:
+// она отменяет контекст решателя и прекращает отправку итогов в канал.
+func (ps *FliperSolver[T]) stream(n int) (<-chan Settlement[T], func()) {
+	ctx, cancel := ps.initContext()
+	quit := make(chan struct{})
+	done := ps.run(ctx, n, nil)
+	pp, _ := ps.snapshot()
+	out := make(chan Settlement[T], len(pp))
+
+	go func() {
+		defer func() {
+			cancel()
+			close(out)
+		}()
+		var errorCount int
+		for i := range done {
+			s := ps.settle(i)
+			if s.Err != nil && n > 0 {
+				if errorCount++; errorCount >= n {
+					cancel()
+				}
+			}
+			select {
+			case out <- s:
+			case <-quit:
+			}
+		}
+	}()
+
+	var once sync.Once
+	return out, func() {
+		cancel()
+		once.Do(func() {
+			close(quit)
+		})
+	}
+}
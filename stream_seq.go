@@ -0,0 +1,20 @@
+//go:build go1.23
+
+package pipers
+
+import "iter"
+
+// StreamSeq — вариант Stream в виде итератора по парам (индекс задачи, итог).
+// Если цикл прерван досрочно, запуск оставшихся задач прекращается,
+// а контекст решателя отменяется.
+func (ps *FliperSolver[T]) StreamSeq(n int) iter.Seq2[int, Settlement[T]] {
+	return func(yield func(int, Settlement[T]) bool) {
+		out, stop := ps.stream(n)
+		defer stop()
+		for s := range out {
+			if !yield(s.Index, s) {
+				return
+			}
+		}
+	}
+}
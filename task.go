@@ -0,0 +1,177 @@
+package pipers
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TaskOption задаёт индивидуальные настройки отдельной задачи,
+// переопределяющие общие настройки FliperSolver.
+type TaskOption func(*taskOptions)
+
+type taskOptions struct {
+	retry    *RetryPolicy
+	timeout  time.Duration
+	weight   int64
+	priority int
+	hedge    *Hedge
+	key      any
+}
+
+// WithRetry задаёт политику повторов для конкретной задачи.
+func WithRetry(policy RetryPolicy) TaskOption {
+	return func(o *taskOptions) {
+		o.retry = &policy
+	}
+}
+
+// WithTimeout ограничивает время выполнения конкретной задачи.
+// Задача получает дочерний контекст с этим таймаутом, а по его истечении
+// завершается с ошибкой TimeoutError.
+func WithTimeout(d time.Duration) TaskOption {
+	return func(o *taskOptions) {
+		o.timeout = d
+	}
+}
+
+// WithWeight задаёт вес задачи для ограничения FliperSolver.WeightLimit.
+func WithWeight(weight int64) TaskOption {
+	return func(o *taskOptions) {
+		o.weight = weight
+	}
+}
+
+// WithHedge задаёт подстраховочный запуск для конкретной задачи (см. FliperSolver.Hedge).
+func WithHedge(h Hedge) TaskOption {
+	return func(o *taskOptions) {
+		o.hedge = &h
+	}
+}
+
+// WithKey задаёт ключ задачи для дедупликации: задачи решателя с одинаковым ключом
+// выполняются одним Flight, результат которого получают все их индексы.
+// Ключ должен быть сравнимым значением. См. также FliperSolver.Dedup.
+func WithKey(key any) TaskOption {
+	return func(o *taskOptions) {
+		o.key = key
+	}
+}
+
+// WithPriority задаёт приоритет задачи. Пока задачи ждут свободного слота,
+// первыми запускаются задачи с большим приоритетом, при равном — добавленные раньше.
+func WithPriority(priority int) TaskOption {
+	return func(o *taskOptions) {
+		o.priority = priority
+	}
+}
+
+// task хранит функцию задачи вместе с её индексом и индивидуальными настройками.
+// Для Flight, добавленных через Add, fn == nil: такие задачи выполняются как есть.
+type task[T any] struct {
+	index  int
+	fn     func(ctx context.Context) (T, error)
+	queued time.Time
+	taskOptions
+
+	mu       sync.Mutex
+	start    time.Time
+	end      time.Time
+	attempts int
+	prepaid  bool          // токен RateLimiter для следующей попытки уже получен (см. pay)
+	running  int           // горутины задачи, которые ещё не вернулись (см. spawn)
+	idle     chan struct{} // закрывается, когда running опускается до нуля
+}
+
+func (t *task[T]) begin() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.start = time.Now()
+}
+
+func (t *task[T]) finish() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.end = time.Now()
+}
+
+func (t *task[T]) attempt() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.attempts++
+}
+
+func (t *task[T]) prepay() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.prepaid = true
+}
+
+// pay получает токен rl для очередной попытки задачи. Первую попытку оплачивает
+// токен, полученный планировщиком при запуске задачи; повторы, дубликаты Hedge
+// и фоновое обновление кеша ждут собственного токена.
+func (t *task[T]) pay(ctx context.Context, rl *RateLimiter) error {
+	t.mu.Lock()
+	prepaid := t.prepaid
+	t.prepaid = false
+	t.mu.Unlock()
+	if prepaid {
+		return nil
+	}
+	return rl.Wait(ctx)
+}
+
+// spawn выполняет f в отдельной горутине, учитывая её в running.
+func (t *task[T]) spawn(f func()) {
+	t.mu.Lock()
+	t.running++
+	t.mu.Unlock()
+	go func() {
+		defer func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if t.running--; t.running == 0 && t.idle != nil {
+				close(t.idle)
+				t.idle = nil
+			}
+		}()
+		f()
+	}()
+}
+
+// whenIdle вызывает f, когда вернутся все горутины задачи, запущенные через spawn:
+// сразу, если таких нет, иначе в фоне.
+func (t *task[T]) whenIdle(f func()) {
+	t.mu.Lock()
+	if t.running == 0 {
+		t.mu.Unlock()
+		f()
+		return
+	}
+	if t.idle == nil {
+		t.idle = make(chan struct{})
+	}
+	idle := t.idle
+	t.mu.Unlock()
+	go func() {
+		<-idle
+		f()
+	}()
+}
+
+// attempted возвращает число сделанных попыток.
+func (t *task[T]) attempted() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.attempts
+}
+
+// stats дополняет итог задачи временем выполнения и числом попыток.
+func (t *task[T]) stats(s *Settlement[T]) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.fn == nil {
+		return
+	}
+	s.Start, s.End, s.Attempts = t.start, t.end, t.attempts
+}
@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kozhurkin/pipers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAIMDIncrease(t *testing.T) {
+	var g gauge
+	ctrl := &pipers.AIMD{Min: 1, Max: 8}
+
+	pp := pipers.FromArgs(make([]int, 60), func(i int, _ int) (int, error) {
+		g.enter()
+		defer g.leave()
+		<-time.After(time.Millisecond)
+		return i, nil
+	}).Adaptive(ctrl)
+
+	err := pp.FirstError()
+
+	fmt.Println(g.history, ctrl.Limit())
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, g.history[0])
+	assert.Equal(t, 8, ctrl.Limit())
+	assert.Equal(t, 8, pp.ConcurrencyLimit())
+	for _, running := range g.history {
+		assert.LessOrEqual(t, running, 8)
+	}
+}
+
+func TestAIMDDecrease(t *testing.T) {
+	throw := errors.New("overload")
+	ctrl := &pipers.AIMD{Min: 2, Initial: 16, Latency: 50 * time.Millisecond}
+
+	pp := pipers.FromArgs([]int{0, 0, 1, 0, 100}, func(i int, v int) (int, error) {
+		<-time.After(time.Duration(v) * time.Millisecond)
+		if i == 0 {
+			return 0, throw
+		}
+		return v, nil
+	}).Adaptive(ctrl)
+
+	errs := pp.ErrorsAll()
+
+	fmt.Println(errs, ctrl.Limit())
+
+	assert.Len(t, errs, 1)
+	// 16 -> 8 (error) -> 8.125 -> 8.25 -> 8.37 -> 4.18 (latency)
+	assert.Equal(t, 4, ctrl.Limit())
+}
+
+func TestAIMDShared(t *testing.T) {
+	var g gauge
+	ctrl := &pipers.AIMD{Min: 2, Max: 2}
+
+	handler := func(i int, _ int) (int, error) {
+		g.enter()
+		defer g.leave()
+		<-time.After(5 * time.Millisecond)
+		return i, nil
+	}
+	a := pipers.FromArgs(make([]int, 4), handler).Adaptive(ctrl)
+	b := pipers.FromArgs(make([]int, 4), handler).Adaptive(ctrl)
+
+	errs := make(chan error, 2)
+	go func() { errs <- a.FirstError() }()
+	go func() { errs <- b.FirstError() }()
+	assert.Nil(t, <-errs)
+	assert.Nil(t, <-errs)
+
+	peak := g.peak()
+	fmt.Println(len(g.history), peak)
+	// 8 2
+
+	assert.Len(t, g.history, 8)
+	assert.Equal(t, 2, peak)
+}
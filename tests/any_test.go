@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kozhurkin/pipers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFirstSuccess(t *testing.T) {
+	ts := time.Now()
+	var canceled int32
+
+	backend := func(d time.Duration, err error) func(context.Context) (string, error) {
+		return func(ctx context.Context) (string, error) {
+			select {
+			case <-time.After(d):
+				return d.String(), err
+			case <-ctx.Done():
+				atomic.AddInt32(&canceled, 1)
+				return "", ctx.Err()
+			}
+		}
+	}
+
+	value, index, err := pipers.FromFuncsCtx(
+		backend(100*time.Millisecond, nil),
+		backend(time.Millisecond, errors.New("unavailable")),
+		backend(3*time.Millisecond, nil),
+		backend(200*time.Millisecond, nil),
+	).FirstSuccess()
+
+	fmt.Println(value, index, err, time.Since(ts))
+	// 3ms 2 <nil> 3.1ms
+
+	assert.Nil(t, err)
+	assert.Equal(t, "3ms", value)
+	assert.Equal(t, 2, index)
+	// the slower backends are not waited for
+	assert.Less(t, time.Since(ts), 50*time.Millisecond)
+
+	<-time.After(20 * time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&canceled))
+}
+
+func TestAnyAllFailed(t *testing.T) {
+	errA, errB := errors.New("a"), errors.New("b")
+
+	value, index, err := pipers.Any(context.Background(),
+		func(ctx context.Context) (int, error) { return 1, errA },
+		func(ctx context.Context) (int, error) { <-time.After(time.Millisecond); return 2, errB },
+	)
+
+	fmt.Println(value, index, err)
+	// 0 -1 task 0: a
+	// task 1: b
+
+	var all *pipers.AllFailedError
+	assert.ErrorAs(t, err, &all)
+	assert.Equal(t, map[int]error{0: errA, 1: errB}, all.Errs.ByIndex())
+	assert.ErrorIs(t, err, errA)
+	assert.ErrorIs(t, err, errB)
+	assert.Equal(t, 0, value)
+	assert.Equal(t, -1, index)
+
+	_, _, err = pipers.Any[int](context.Background())
+	assert.Equal(t, pipers.ErrNoTasks, err)
+}
@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kozhurkin/pipers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker(t *testing.T) {
+	ts := time.Now()
+	var calls int32
+	down := errors.New("connection refused")
+	breaker := &pipers.Breaker{Failures: 3, Cooldown: 5 * time.Millisecond}
+
+	pp := pipers.FromArgs(make([]int, 10), func(i int, _ int) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		<-time.After(time.Millisecond)
+		return 0, down
+	}).Concurrency(1).Breaker(breaker)
+
+	errs := pp.ErrorsAll()
+
+	fmt.Println(len(errs), atomic.LoadInt32(&calls), breaker.State(), time.Since(ts))
+	// 10 3 open 3.5ms
+
+	assert.Len(t, errs, 10)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	assert.ErrorIs(t, errs[0], down)
+	assert.ErrorIs(t, errs[9], pipers.ErrCircuitOpen)
+	assert.Equal(t, []int{3, 4, 5, 6, 7, 8, 9}, pipers.Errors(errs[3:]).Indexes())
+	assert.Equal(t, pipers.BreakerOpen, breaker.State())
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	var healthy int32
+	breaker := &pipers.Breaker{Failures: 1, Cooldown: 3 * time.Millisecond, Probes: 2}
+	run := func(n int) pipers.Errors {
+		return pipers.FromArgs(make([]int, n), func(i int, _ int) (int, error) {
+			if atomic.LoadInt32(&healthy) == 0 {
+				return 0, errors.New("down")
+			}
+			return 1, nil
+		}).Concurrency(1).Breaker(breaker).ErrorsAll()
+	}
+
+	run(1)
+	assert.Equal(t, pipers.BreakerOpen, breaker.State())
+
+	<-time.After(3 * time.Millisecond)
+	assert.Equal(t, pipers.BreakerHalfOpen, breaker.State())
+	// a failed probe opens the circuit again
+	run(1)
+	assert.Equal(t, pipers.BreakerOpen, breaker.State())
+
+	<-time.After(3 * time.Millisecond)
+	atomic.StoreInt32(&healthy, 1)
+	errs := run(4)
+
+	fmt.Println(errs, breaker.State())
+	// [] closed
+
+	assert.Len(t, errs, 0)
+	assert.Equal(t, pipers.BreakerClosed, breaker.State())
+}
@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kozhurkin/pipers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkhead(t *testing.T) {
+	ts := time.Now()
+	var g gauge
+	db := pipers.NewBulkhead("tests/db", 3, 100)
+	assert.Same(t, db, pipers.NewBulkhead("tests/db", 10, 0))
+	assert.Same(t, db, pipers.LookupBulkhead("tests/db"))
+
+	var wg sync.WaitGroup
+	for k := 0; k < 3; k++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := pipers.FromArgs(make([]int, 4), func(i int, _ int) (int, error) {
+				g.enter()
+				defer g.leave()
+				<-time.After(2 * time.Millisecond)
+				return i, nil
+			}).Concurrency(4).Bulkhead(db).Resolve()
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+
+	var max int
+	for _, running := range g.history {
+		if running > max {
+			max = running
+		}
+	}
+
+	fmt.Println(max, time.Since(ts))
+	// 3 8.4ms
+
+	assert.Equal(t, 3, max)
+	// 12 tasks by 2ms, at most 3 at a time
+	assert.GreaterOrEqual(t, int(time.Since(ts).Milliseconds()), 8)
+}
+
+func TestBulkheadFull(t *testing.T) {
+	cache := pipers.NewBulkhead("tests/cache", 1, 2)
+
+	errs := pipers.FromArgs(make([]int, 5), func(i int, _ int) (int, error) {
+		<-time.After(2 * time.Millisecond)
+		return i, nil
+	}).Bulkhead(cache).ErrorsAll()
+
+	fmt.Println(errs)
+	// [task 3: pipers: bulkhead full: tests/cache task 4: pipers: bulkhead full: tests/cache]
+
+	assert.Len(t, errs, 2)
+	for _, err := range errs {
+		assert.True(t, errors.Is(err, pipers.ErrBulkheadFull))
+	}
+	assert.Equal(t, 0, cache.Waiting())
+}
@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kozhurkin/pipers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	var calls int32
+	lru := pipers.NewLRU[int](100)
+	square := func(i int, n int) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		<-time.After(2 * time.Millisecond)
+		return n * n, nil
+	}
+	policy := pipers.CachePolicy{TTL: time.Minute}
+
+	first, err := pipers.FromArgsDedup([]int{1, 2, 3}, square).Cache(lru, policy).Resolve()
+	assert.Nil(t, err)
+
+	ts := time.Now()
+	second, err := pipers.FromArgsDedup([]int{2, 3, 4}, square).Cache(lru, policy).Resolve()
+
+	fmt.Println(first, second, err, atomic.LoadInt32(&calls), time.Since(ts))
+	// [1 4 9] [4 9 16] <nil> 4 2.1ms
+
+	assert.Nil(t, err)
+	assert.Equal(t, []int{4, 9, 16}, second)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+	assert.Equal(t, 4, lru.Len())
+}
+
+func TestCacheErrors(t *testing.T) {
+	var calls int32
+	boom := errors.New("boom")
+	fail := func(i int, key string) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		return 0, boom
+	}
+
+	lru := pipers.NewLRU[int](10)
+	for k := 0; k < 2; k++ {
+		_, err := pipers.FromArgsDedup([]string{"a"}, fail).Cache(lru, pipers.CachePolicy{TTL: time.Minute}).Resolve()
+		assert.ErrorIs(t, err, boom)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	policy := pipers.CachePolicy{TTL: time.Minute, ErrorTTL: time.Minute}
+	for k := 0; k < 2; k++ {
+		_, err := pipers.FromArgsDedup([]string{"a"}, fail).Cache(lru, policy).Resolve()
+		assert.ErrorIs(t, err, boom)
+	}
+
+	fmt.Println(atomic.LoadInt32(&calls))
+	// 3
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestCacheStale(t *testing.T) {
+	var version int32
+	fetch := func(i int, key string) (int32, error) {
+		<-time.After(3 * time.Millisecond)
+		return atomic.AddInt32(&version, 1), nil
+	}
+	lru := pipers.NewLRU[int32](10)
+	policy := pipers.CachePolicy{TTL: 2 * time.Millisecond, Stale: time.Minute}
+	resolve := func() int32 {
+		res, err := pipers.FromArgsDedup([]string{"config"}, fetch).Cache(lru, policy).Resolve()
+		assert.Nil(t, err)
+		return res[0]
+	}
+
+	first := resolve()
+	<-time.After(3 * time.Millisecond)
+
+	ts := time.Now()
+	stale := resolve()
+	elapsed := time.Since(ts)
+	<-time.After(5 * time.Millisecond)
+	fresh := resolve()
+
+	fmt.Println(first, stale, fresh, elapsed)
+	// 1 1 2 0.05ms
+
+	assert.Equal(t, int32(1), stale)
+	assert.Equal(t, int32(2), fresh)
+	assert.Less(t, elapsed, 2*time.Millisecond)
+}
+
+func TestCacheRateLimit(t *testing.T) {
+	var calls int32
+	lru := pipers.NewLRU[int](100)
+	square := func(i int, n int) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		return n * n, nil
+	}
+	policy := pipers.CachePolicy{TTL: time.Minute}
+	keys := []int{1, 2, 3, 4, 5, 6}
+
+	_, err := pipers.FromArgsDedup(keys, square).Cache(lru, policy).Resolve()
+	assert.Nil(t, err)
+
+	ts := time.Now()
+	res, err := pipers.FromArgsDedup(keys, square).Cache(lru, policy).RateLimit(10, 1).Resolve()
+
+	fmt.Println(res, err, atomic.LoadInt32(&calls), time.Since(ts))
+	// [1 4 9 16 25 36] <nil> 6 0.05ms
+
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 4, 9, 16, 25, 36}, res)
+	assert.Equal(t, int32(6), atomic.LoadInt32(&calls))
+	// cache hits don't wait for tokens: six of them would take 500ms otherwise
+	assert.Less(t, time.Since(ts), 250*time.Millisecond)
+}
+
+func TestLRU(t *testing.T) {
+	lru := pipers.NewLRU[string](2)
+	lru.Set("a", pipers.CacheEntry[string]{Value: "A"})
+	lru.Set("b", pipers.CacheEntry[string]{Value: "B"})
+	lru.Get("a")
+	lru.Set("c", pipers.CacheEntry[string]{Value: "C"})
+
+	_, okA := lru.Get("a")
+	_, okB := lru.Get("b")
+	_, okC := lru.Get("c")
+
+	fmt.Println(okA, okB, okC, lru.Len())
+	// true false true 2
+
+	assert.Equal(t, []bool{true, false, true}, []bool{okA, okB, okC})
+	assert.Equal(t, 2, lru.Len())
+}
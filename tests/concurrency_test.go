@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kozhurkin/pipers"
+	"github.com/stretchr/testify/assert"
+)
+
+// gauge tracks how many tasks are running at once.
+type gauge struct {
+	mu      sync.Mutex
+	running int
+	history []int
+}
+
+func (g *gauge) enter() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.running++
+	g.history = append(g.history, g.running)
+}
+
+func (g *gauge) leave() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.running--
+}
+
+// peak returns the largest number of tasks that ran at once.
+func (g *gauge) peak() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	peak := 0
+	for _, n := range g.history {
+		if n > peak {
+			peak = n
+		}
+	}
+	return peak
+}
+
+func TestConcurrencyIncrease(t *testing.T) {
+	ts := time.Now()
+	var g gauge
+
+	pp := pipers.FromArgs(make([]int, 6), func(i int, _ int) (int, error) {
+		g.enter()
+		defer g.leave()
+		<-time.After(20 * time.Millisecond)
+		return i, nil
+	}).Concurrency(1)
+
+	go func() {
+		<-time.After(5 * time.Millisecond)
+		pp.Concurrency(3)
+	}()
+
+	err := pp.FirstError()
+
+	fmt.Println(g.history, time.Since(ts))
+	// [1 2 3 3 3 3] 45.00ms
+
+	assert.Nil(t, err)
+	assert.Equal(t, 3, pp.ConcurrencyLimit())
+	assert.Equal(t, []int{1, 2, 3}, g.history[:3])
+	for _, n := range g.history {
+		assert.LessOrEqual(t, n, 3)
+	}
+}
+
+func TestConcurrencyDecrease(t *testing.T) {
+	ts := time.Now()
+	var g gauge
+
+	pp := pipers.FromArgs(make([]int, 6), func(i int, _ int) (int, error) {
+		g.enter()
+		defer g.leave()
+		<-time.After(20 * time.Millisecond)
+		return i, nil
+	}).Concurrency(3)
+
+	go func() {
+		<-time.After(5 * time.Millisecond)
+		pp.Concurrency(1)
+	}()
+
+	results, err := pp.Resolve()
+
+	fmt.Println(g.history, time.Since(ts))
+	// [1 2 3 1 1 1] 80.00ms
+
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5}, []int(results))
+	assert.Equal(t, []int{1, 2, 3, 1, 1, 1}, g.history)
+}
@@ -0,0 +1,172 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kozhurkin/pipers"
+	"github.com/stretchr/testify/assert"
+)
+
+// trail records node events in the order they happen.
+type trail struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (tr *trail) add(event string) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.events = append(tr.events, event)
+}
+
+// at returns the position of event in the trail.
+func (tr *trail) at(event string) int {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	for k, e := range tr.events {
+		if e == event {
+			return k
+		}
+	}
+	return -1
+}
+
+func TestDAG(t *testing.T) {
+	ts := time.Now()
+	var tr trail
+	node := func(name string, ms int, f func(deps []int) int) func(context.Context, []int) (int, error) {
+		return func(ctx context.Context, deps []int) (int, error) {
+			tr.add("start:" + name)
+			defer tr.add("end:" + name)
+			<-time.After(time.Duration(ms) * time.Millisecond)
+			return f(deps), nil
+		}
+	}
+
+	dag := pipers.NewDAG[int]().
+		Node("sum", node("sum", 2, func(deps []int) int { return deps[0] + deps[1] }), "a", "b").
+		Node("a", node("a", 10, func([]int) int { return 1 })).
+		Node("b", node("b", 20, func([]int) int { return 2 })).
+		Node("double", node("double", 0, func(deps []int) int { return deps[0] * 2 }), "sum")
+
+	results, err := dag.Resolve()
+
+	fmt.Println(results, err, time.Since(ts))
+	// map[a:1 b:2 double:6 sum:3] <nil> 22.3ms
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{"a": 1, "b": 2, "sum": 3, "double": 6}, results)
+
+	// a and b run in parallel, sum waits for both, double waits for sum
+	assert.Less(t, tr.at("start:b"), tr.at("end:a"))
+	assert.Less(t, tr.at("end:a"), tr.at("start:sum"))
+	assert.Less(t, tr.at("end:b"), tr.at("start:sum"))
+	assert.Less(t, tr.at("end:sum"), tr.at("start:double"))
+
+	names := make([]string, 0, 4)
+	for _, s := range dag.Settled() {
+		names = append(names, s.Name)
+	}
+	assert.Equal(t, []string{"a", "b", "sum", "double"}, names)
+}
+
+func TestDAGSkip(t *testing.T) {
+	boom := errors.New("boom")
+	calls := make(chan string, 4)
+	node := func(name string, err error) func(context.Context, []int) (int, error) {
+		return func(ctx context.Context, deps []int) (int, error) {
+			calls <- name
+			return len(deps), err
+		}
+	}
+
+	dag := pipers.NewDAG[int]().
+		Node("a", node("a", nil)).
+		Node("b", node("b", boom)).
+		Node("c", node("c", nil), "a", "b").
+		Node("d", node("d", nil), "c").
+		Node("e", node("e", nil), "a")
+
+	errs := dag.ErrorsAll()
+	close(calls)
+
+	fmt.Println(errs, len(calls))
+	// [node "b": boom] 3
+
+	assert.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], boom)
+	assert.Len(t, calls, 3)
+
+	statuses := make(map[string]pipers.Status)
+	for _, s := range dag.Settled() {
+		statuses[s.Name] = s.Status
+	}
+	assert.Equal(t, map[string]pipers.Status{
+		"a": pipers.StatusSucceeded,
+		"b": pipers.StatusFailed,
+		"c": pipers.StatusSkipped,
+		"d": pipers.StatusSkipped,
+		"e": pipers.StatusSucceeded,
+	}, statuses)
+
+	var ue *pipers.UpstreamError
+	assert.ErrorAs(t, dag.Settled()[3].Err, &ue)
+	assert.Equal(t, "b", ue.Node)
+}
+
+func TestDAGCycle(t *testing.T) {
+	noop := func(ctx context.Context, deps []int) (int, error) { return 0, nil }
+
+	dag := pipers.NewDAG[int]().
+		Node("a", noop).
+		Node("b", noop, "a", "d").
+		Node("c", noop, "b").
+		Node("d", noop, "c")
+
+	err := dag.Build()
+
+	fmt.Println(err)
+	// pipers: dependency cycle: b -> d -> c -> b
+
+	var ce *pipers.CycleError
+	assert.ErrorAs(t, err, &ce)
+	assert.Equal(t, []string{"b", "d", "c", "b"}, ce.Path)
+
+	_, err = dag.Resolve()
+	assert.Equal(t, ce, err)
+
+	_, err = pipers.NewDAG[int]().Node("a", noop, "x").Resolve()
+	assert.EqualError(t, err, `pipers: node "a" depends on unknown node "x"`)
+}
+
+func TestDAGConcurrency(t *testing.T) {
+	ts := time.Now()
+	var g gauge
+	node := func(ctx context.Context, deps []int) (int, error) {
+		g.enter()
+		defer g.leave()
+		<-time.After(2 * time.Millisecond)
+		return len(deps), nil
+	}
+
+	dag := pipers.NewDAG[int]().Concurrency(2).
+		Node("a", node).
+		Node("b", node).
+		Node("c", node).
+		Node("d", node, "a", "b", "c")
+
+	results, err := dag.Resolve()
+
+	fmt.Println(results, err, g.history, time.Since(ts))
+	// map[a:0 b:0 c:0 d:3] <nil> [1 2 2 1] 6.4ms
+
+	assert.Nil(t, err)
+	assert.Equal(t, 3, results["d"])
+	assert.Equal(t, []int{1, 2}, g.history[:2])
+	assert.Equal(t, 2, g.peak())
+}
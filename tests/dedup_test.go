@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kozhurkin/pipers"
+	"github.com/kozhurkin/singleflight"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDedup(t *testing.T) {
+	ts := time.Now()
+	var calls int32
+	args := []string{"a", "b", "a", "c", "b", "a"}
+
+	pp := pipers.FromArgsDedup(args, func(i int, s string) (string, error) {
+		atomic.AddInt32(&calls, 1)
+		<-time.After(2 * time.Millisecond)
+		return strings.ToUpper(s), nil
+	}).Concurrency(2)
+
+	results, err := pp.Resolve()
+
+	fmt.Println(results, err, atomic.LoadInt32(&calls), time.Since(ts))
+	// [A B A C B A] <nil> 3 4.2ms
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"A", "B", "A", "C", "B", "A"}, results)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	// three unique calls take two rounds with Concurrency(2)
+	assert.GreaterOrEqual(t, time.Since(ts), 4*time.Millisecond)
+}
+
+func TestDedupAcrossSolvers(t *testing.T) {
+	var calls int32
+	group := singleflight.NewGroup[any, int]()
+	fetch := func() (int, error) {
+		atomic.AddInt32(&calls, 1)
+		<-time.After(3 * time.Millisecond)
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([][]int, 3)
+	for k := range results {
+		k := k
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pp := pipers.FromFuncs[int]().Dedup(group)
+			pp.AddFunc(fetch, pipers.WithKey("user:1"))
+			pp.AddFunc(func() (int, error) { return k, nil })
+			results[k], _ = pp.Resolve()
+		}()
+	}
+	wg.Wait()
+
+	fmt.Println(results, atomic.LoadInt32(&calls))
+	// [[42 0] [42 1] [42 2]] 1
+
+	assert.Equal(t, [][]int{{42, 0}, {42, 1}, {42, 2}}, results)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestDedupAcrossSolversCanceled(t *testing.T) {
+	var calls int32
+	group := singleflight.NewGroup[any, int]()
+	fetch := func(ctx context.Context) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		select {
+		case <-time.After(20 * time.Millisecond):
+			return 42, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+
+	// solver a starts the shared call and gives up on it after its other task fails
+	a := pipers.FromFuncs[int]().Dedup(group)
+	a.AddFuncCtx(fetch, pipers.WithKey("user:1"))
+	a.AddFuncCtx(func(ctx context.Context) (int, error) {
+		<-time.After(5 * time.Millisecond)
+		return 0, errors.New("boom")
+	})
+
+	var res []int
+	var err error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		<-time.After(time.Millisecond)
+		b := pipers.FromFuncs[int]().Dedup(group)
+		b.AddFuncCtx(fetch, pipers.WithKey("user:1"))
+		res, err = b.Resolve()
+	}()
+
+	errA := a.FirstError()
+	<-done
+
+	fmt.Println(errA, res, err, atomic.LoadInt32(&calls))
+	// task 1: boom [42] <nil> 2
+
+	assert.EqualError(t, errA, "task 1: boom")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{42}, res)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
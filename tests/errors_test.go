@@ -0,0 +1,52 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/kozhurkin/pipers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskError(t *testing.T) {
+	urls := []string{"https://go.dev", "invalid", "https://github.com", "invalid"}
+	invalid := errors.New("invalid url")
+
+	pp := pipers.FromArgs(urls, func(i int, url string) (int, error) {
+		if url == "invalid" {
+			return 0, invalid
+		}
+		return 200, nil
+	}).Concurrency(1)
+
+	err := pp.FirstError()
+
+	fmt.Println(err)
+	// task 1: invalid url
+
+	var te *pipers.TaskError
+	assert.True(t, errors.As(err, &te))
+	assert.Equal(t, 1, te.Index)
+	assert.ErrorIs(t, err, invalid)
+}
+
+func TestErrorsByIndex(t *testing.T) {
+	throw := errors.New("throw")
+
+	pp := pipers.FromArgs([]int{0, 1, 2, 3, 4}, func(i int, a int) (int, error) {
+		if a%2 == 1 {
+			return 0, throw
+		}
+		return a, nil
+	}).Concurrency(1)
+
+	errs := pp.ErrorsAll()
+
+	fmt.Println(errs, errs.Indexes(), errs.ByIndex())
+	// [task 1: throw task 3: throw] [1 3] map[1:throw 3:throw]
+
+	assert.Equal(t, []int{1, 3}, errs.Indexes())
+	assert.Equal(t, map[int]error{1: throw, 3: throw}, errs.ByIndex())
+	assert.ErrorIs(t, errs.Join(), throw)
+}
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kozhurkin/pipers"
+	"github.com/stretchr/testify/assert"
+)
+
+// stragglers makes the first attempt of the given tasks hang until canceled.
+type stragglers struct {
+	mu       sync.Mutex
+	attempts map[int]int
+	canceled int32
+}
+
+func (s *stragglers) handler(slow ...int) func(ctx context.Context, i int, d time.Duration) (int, error) {
+	s.attempts = make(map[int]int)
+	return func(ctx context.Context, i int, d time.Duration) (int, error) {
+		s.mu.Lock()
+		s.attempts[i]++
+		first := s.attempts[i] == 1
+		s.mu.Unlock()
+		for _, k := range slow {
+			if k == i && first {
+				d = time.Second
+			}
+		}
+		select {
+		case <-time.After(d):
+			return i, nil
+		case <-ctx.Done():
+			atomic.AddInt32(&s.canceled, 1)
+			return 0, ctx.Err()
+		}
+	}
+}
+
+func TestHedge(t *testing.T) {
+	ts := time.Now()
+	var s stragglers
+	args := []time.Duration{time.Millisecond, time.Millisecond, time.Millisecond, time.Millisecond}
+
+	pp := pipers.FromArgsCtx(args, s.handler(1, 3)).Hedge(pipers.Hedge{Delay: 20 * time.Millisecond})
+
+	results, err := pp.Resolve()
+
+	fmt.Println(results, err, pp.Hedges(), time.Since(ts))
+	// [0 1 2 3] <nil> 2 21.1ms
+
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3}, results)
+	assert.Equal(t, 2, pp.Hedges())
+
+	<-time.After(time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&s.canceled))
+	assert.Equal(t, 2, pp.Settled()[1].Attempts)
+	assert.Equal(t, 1, pp.Settled()[2].Attempts)
+}
+
+func TestHedgePercentile(t *testing.T) {
+	ts := time.Now()
+	var s stragglers
+	args := make([]time.Duration, 8)
+	for i := range args {
+		args[i] = 2 * time.Millisecond
+	}
+
+	pp := pipers.FromArgsCtx(args, s.handler(7)).
+		Concurrency(1).
+		Hedge(pipers.Hedge{Delay: time.Hour, Percentile: 90})
+
+	results, err := pp.Resolve()
+
+	fmt.Println(results, err, pp.Hedges(), time.Since(ts))
+	// [0 1 2 3 4 5 6 7] <nil> 1 20.3ms
+
+	assert.Nil(t, err)
+	// the straggler is hedged after ~2ms: with the 1h fallback delay it would
+	// have finished its only attempt after 1s
+	assert.Equal(t, 2, pp.Settled()[7].Attempts)
+	assert.GreaterOrEqual(t, pp.Hedges(), 1)
+}
+
+func TestHedgePerTask(t *testing.T) {
+	var s stragglers
+	handler := s.handler(0, 1)
+
+	pp := pipers.FromFuncsCtx[int]()
+	pp.AddFuncCtx(func(ctx context.Context) (int, error) {
+		return handler(ctx, 0, time.Millisecond)
+	}, pipers.WithHedge(pipers.Hedge{Delay: time.Millisecond}))
+	pp.AddFuncCtx(func(ctx context.Context) (int, error) {
+		return handler(ctx, 1, time.Millisecond)
+	}, pipers.WithTimeout(5*time.Millisecond))
+
+	results, err := pp.Resolve()
+
+	fmt.Println(results, err, pp.Hedges())
+	// [0 0] task 1: pipers: task timeout (5ms) 1
+
+	assert.ErrorIs(t, err, pipers.ErrTaskTimeout)
+	assert.Equal(t, 1, pp.Hedges())
+}
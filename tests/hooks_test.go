@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kozhurkin/pipers"
+	"github.com/stretchr/testify/assert"
+)
+
+// journal records task lifecycle events as "<event>:<index>".
+type journal struct {
+	mu     sync.Mutex
+	events []string
+	last   map[int]pipers.TaskEvent
+}
+
+func (j *journal) hooks() pipers.Hooks {
+	record := func(name string) func(pipers.TaskEvent) {
+		return func(e pipers.TaskEvent) {
+			j.mu.Lock()
+			defer j.mu.Unlock()
+			j.events = append(j.events, fmt.Sprintf("%v:%v", name, e.Index))
+			if j.last == nil {
+				j.last = make(map[int]pipers.TaskEvent)
+			}
+			j.last[e.Index] = e
+		}
+	}
+	return pipers.Hooks{
+		OnStart:   record("start"),
+		OnSuccess: record("success"),
+		OnError:   record("error"),
+		OnCancel:  record("cancel"),
+	}
+}
+
+func (j *journal) sorted() []string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	res := append([]string(nil), j.events...)
+	sort.Strings(res)
+	return res
+}
+
+func TestHooks(t *testing.T) {
+	var j journal
+	var starts int
+	var mu sync.Mutex
+	boom := errors.New("boom")
+
+	pp := pipers.FromArgsCtx([]int{1, 2, 3, 20}, func(ctx context.Context, i int, ms int) (int, error) {
+		if i == 2 {
+			return 0, boom
+		}
+		select {
+		case <-time.After(time.Duration(ms) * time.Millisecond):
+			return ms, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}).
+		Hooks(j.hooks()).
+		Hooks(pipers.Hooks{OnStart: func(pipers.TaskEvent) {
+			mu.Lock()
+			starts++
+			mu.Unlock()
+		}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	errs := pp.Context(ctx).ErrorsAll()
+	<-pp.Tail()
+
+	fmt.Println(errs, j.sorted())
+	// [task 2: boom context deadline exceeded] [cancel:3 error:2 start:0 start:1 start:2 start:3 success:0 success:1]
+
+	assert.Equal(t, []string{"cancel:3", "error:2", "start:0", "start:1", "start:2", "start:3", "success:0", "success:1"}, j.sorted())
+	assert.Equal(t, 4, starts)
+	assert.ErrorIs(t, j.last[2].Err, boom)
+	assert.GreaterOrEqual(t, j.last[1].Duration, 2*time.Millisecond)
+}
+
+func TestHooksAttempts(t *testing.T) {
+	var j journal
+	var calls int
+
+	pp := pipers.FromFuncs(func() (int, error) {
+		if calls++; calls < 3 {
+			return 0, errors.New("flaky")
+		}
+		return calls, nil
+	}).Retry(pipers.RetryPolicy{MaxAttempts: 5}).Hooks(j.hooks())
+
+	results, err := pp.Resolve()
+
+	fmt.Println(results, err, j.sorted(), j.last[0].Attempt)
+	// [3] <nil> [start:0 success:0] 3
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"start:0", "success:0"}, j.sorted())
+	assert.Equal(t, 3, j.last[0].Attempt)
+}
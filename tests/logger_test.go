@@ -0,0 +1,75 @@
+//go:build go1.21
+
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kozhurkin/pipers"
+	"github.com/stretchr/testify/assert"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent writes.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) lines() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strings.Split(strings.TrimSpace(b.buf.String()), "\n")
+}
+
+func TestLogger(t *testing.T) {
+	var buf syncBuffer
+	log := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	pp := pipers.FromArgs([]int{1, 20, 50}, func(i int, ms int) (int, error) {
+		<-time.After(time.Duration(ms) * time.Millisecond)
+		if i == 1 {
+			return 0, errors.New("boom")
+		}
+		return ms, nil
+	}).Logger(log).Concurrency(2)
+	pp.AddFunc(func() (int, error) { return 0, nil })
+
+	err := pp.FirstError()
+	<-pp.Tail()
+	<-time.After(time.Millisecond)
+
+	messages := make(map[string]int)
+	for _, line := range buf.lines() {
+		if m := regexp.MustCompile(`msg="([^"]*)"`).FindStringSubmatch(line); m != nil {
+			messages[m[1]]++
+		}
+	}
+
+	fmt.Println(err, messages)
+	// task 1: boom map[pipers: all tasks finished:1 pipers: error limit reached:1 pipers: scheduling stopped:1 pipers: task failed:1 ...]
+
+	assert.ErrorContains(t, err, "boom")
+	// tasks added before .Logger() are reported as queued too
+	assert.Equal(t, 4, messages["pipers: task queued"])
+	// the slot freed by the failed task may go to the last one before scheduling stops
+	assert.Contains(t, []int{3, 4}, messages["pipers: task started"])
+	assert.Equal(t, 1, messages["pipers: task failed"])
+	assert.Equal(t, 1, messages["pipers: error limit reached"])
+	assert.Equal(t, 1, messages["pipers: scheduling stopped"])
+	assert.Equal(t, 1, messages["pipers: all tasks finished"])
+	assert.Contains(t, strings.Join(buf.lines(), "\n"), `level=WARN msg="pipers: task failed" index=1`)
+}
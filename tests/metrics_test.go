@@ -0,0 +1,184 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kozhurkin/pipers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	c := pipers.NewCollector("metrics")
+	defer c.Unregister()
+	assert.Equal(t, c, pipers.NewCollector("metrics"))
+
+	pp := pipers.FromArgsCtx([]int{20, 20, 20, 50}, func(ctx context.Context, i int, ms int) (int, error) {
+		select {
+		case <-time.After(time.Duration(ms) * time.Millisecond):
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+		if i == 1 {
+			return 0, errors.New("boom")
+		}
+		return ms, nil
+	}).Metrics(c).Concurrency(4)
+
+	go func() {
+		<-time.After(10 * time.Millisecond)
+		s := c.Snapshot()
+		assert.Equal(t, uint64(4), s.Started)
+		assert.Equal(t, int64(4), s.InFlight)
+	}()
+
+	errs := pp.ErrorsAll()
+	<-time.After(10 * time.Millisecond)
+	s := c.Snapshot()
+
+	fmt.Println(len(errs), s.Started, s.Succeeded, s.Failed, s.Canceled, s.InFlight)
+	// 1 4 3 1 0 0
+
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "metrics", s.Name)
+	assert.Equal(t, uint64(4), s.Started)
+	assert.Equal(t, uint64(3), s.Succeeded)
+	assert.Equal(t, uint64(1), s.Failed)
+	assert.Equal(t, uint64(0), s.Canceled)
+	assert.Equal(t, int64(0), s.InFlight)
+	assert.Equal(t, uint64(4), s.Duration.Count)
+	assert.GreaterOrEqual(t, s.Duration.Sum, 0.11)
+	// 20ms is above the 10ms bucket and below the 25ms one
+	assert.Equal(t, uint64(0), s.Duration.Counts[3])
+	assert.Equal(t, uint64(3), s.Duration.Counts[4])
+	assert.Equal(t, uint64(4), s.Duration.Counts[len(s.Duration.Counts)-1])
+}
+
+func TestMetricsQueueWait(t *testing.T) {
+	c := pipers.NewCollector("metrics-queue")
+	defer c.Unregister()
+
+	pp := pipers.FromArgsCtx([]int{20, 20, 20}, func(ctx context.Context, i int, ms int) (int, error) {
+		select {
+		case <-time.After(time.Duration(ms) * time.Millisecond):
+			return ms, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}).Metrics(c).Concurrency(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	pp.Context(ctx).ErrorsAll()
+	<-time.After(10 * time.Millisecond)
+	s := c.Snapshot()
+
+	fmt.Println(s.Started, s.Succeeded, s.Canceled, s.InFlight)
+	// 2 1 1 0
+
+	assert.Equal(t, uint64(2), s.Started)
+	assert.Equal(t, uint64(1), s.Succeeded)
+	assert.Equal(t, uint64(1), s.Canceled)
+	assert.Equal(t, uint64(2), s.QueueWait.Count)
+	// the second task waited for the first one
+	assert.GreaterOrEqual(t, s.QueueWait.Sum, 0.02)
+}
+
+func TestMetricsShared(t *testing.T) {
+	a := pipers.NewCollector("metrics-a")
+	b := pipers.NewCollector("metrics-b")
+	defer a.Unregister()
+	defer b.Unregister()
+	before := pipers.Aggregate()
+
+	handler := func(i int, v int) (int, error) { return v, nil }
+	pipers.FromArgs([]int{1, 2}, handler).Metrics(a).Resolve()
+	pipers.FromArgs([]int{3}, handler).Metrics(a).Resolve()
+	pipers.FromArgs([]int{4, 5, 6}, handler).Metrics(b).Resolve()
+
+	after := pipers.Aggregate()
+	fmt.Println(a.Snapshot().Started, b.Snapshot().Started, after.Started-before.Started)
+	// 3 3 6
+
+	assert.Equal(t, uint64(3), a.Snapshot().Started)
+	assert.Equal(t, uint64(3), b.Snapshot().Succeeded)
+	assert.Equal(t, "", after.Name)
+	assert.Equal(t, uint64(6), after.Started-before.Started)
+	assert.Equal(t, uint64(6), after.Duration.Count-before.Duration.Count)
+}
+
+func TestMetricsPrometheus(t *testing.T) {
+	c := pipers.NewCollector(`metrics "prom"`)
+	defer c.Unregister()
+	pipers.FromArgs([]int{1, 2, 3}, func(i int, v int) (int, error) {
+		if v == 2 {
+			return 0, errors.New("boom")
+		}
+		return v, nil
+	}).Metrics(c).ErrorsAll()
+
+	var buf bytes.Buffer
+	assert.NoError(t, pipers.WritePrometheus(&buf))
+	out := buf.String()
+
+	for _, line := range []string{
+		"# TYPE pipers_tasks_started_total counter",
+		`pipers_tasks_started_total{solver="metrics \"prom\""} 3`,
+		`pipers_tasks_succeeded_total{solver="metrics \"prom\""} 2`,
+		`pipers_tasks_failed_total{solver="metrics \"prom\""} 1`,
+		`pipers_tasks_canceled_total{solver="metrics \"prom\""} 0`,
+		"# TYPE pipers_tasks_in_flight gauge",
+		`pipers_tasks_in_flight{solver="metrics \"prom\""} 0`,
+		"# TYPE pipers_task_duration_seconds histogram",
+		`pipers_task_duration_seconds_bucket{solver="metrics \"prom\"",le="0.001"} 3`,
+		`pipers_task_duration_seconds_bucket{solver="metrics \"prom\"",le="+Inf"} 3`,
+		`pipers_task_duration_seconds_count{solver="metrics \"prom\""} 3`,
+		`pipers_task_queue_wait_seconds_count{solver="metrics \"prom\""} 3`,
+	} {
+		assert.Contains(t, strings.Split(out, "\n"), line)
+	}
+}
+
+func TestMetricsExpvar(t *testing.T) {
+	c := pipers.NewCollector("metrics-expvar")
+	defer c.Unregister()
+	pipers.FromArgs([]int{1, 2}, func(i int, v int) (int, error) { return v, nil }).Metrics(c).Resolve()
+
+	pipers.PublishExpvar("pipers_metrics_test")
+	pipers.PublishExpvar("pipers_metrics_test")
+	var vars map[string]pipers.MetricsSnapshot
+	assert.NoError(t, json.Unmarshal([]byte(expvar.Get("pipers_metrics_test").String()), &vars))
+
+	fmt.Println(vars["metrics-expvar"].Started, vars["metrics-expvar"].Succeeded)
+	// 2 2
+
+	assert.Equal(t, uint64(2), vars["metrics-expvar"].Succeeded)
+	assert.GreaterOrEqual(t, vars["total"].Started, uint64(2))
+}
+
+func TestMetricsUnregister(t *testing.T) {
+	c := pipers.NewCollector("metrics-unregister")
+	pipers.FromArgs([]int{1}, func(i int, v int) (int, error) { return v, nil }).Metrics(c).Resolve()
+	c.Unregister()
+
+	fresh := pipers.NewCollector("metrics-unregister")
+	defer fresh.Unregister()
+
+	var buf bytes.Buffer
+	assert.NoError(t, pipers.WritePrometheus(&buf))
+
+	fmt.Println(c.Snapshot().Started, fresh.Snapshot().Started)
+	// 1 0
+
+	assert.NotSame(t, c, fresh)
+	assert.Equal(t, uint64(1), c.Snapshot().Started)
+	assert.Equal(t, uint64(0), fresh.Snapshot().Started)
+	assert.Contains(t, buf.String(), `pipers_tasks_started_total{solver="metrics-unregister"} 0`)
+}
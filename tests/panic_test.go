@@ -0,0 +1,62 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kozhurkin/pipers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPanicRecovery(t *testing.T) {
+	args := []int{1, 2, 0, 4}
+
+	pp := pipers.FromArgs(args, func(i int, a int) (int, error) {
+		return 100 / a, nil
+	})
+
+	results, err := pp.Resolve()
+
+	fmt.Println(results, err)
+	// [100 50 0 25] task 2: panic: runtime error: integer divide by zero
+
+	var pe *pipers.PanicError
+	assert.True(t, errors.As(err, &pe))
+	assert.Equal(t, 2, pe.Index)
+	assert.NotEmpty(t, pe.Stack)
+	assert.Contains(t, err.Error(), "divide by zero")
+
+	<-pp.Tail()
+}
+
+func TestPanicErrorValue(t *testing.T) {
+	throw := errors.New("throw")
+
+	pp := pipers.FromFuncs(
+		func() (int, error) { panic(throw) },
+		func() (int, error) { panic("oops") },
+	)
+
+	errs := pp.ErrorsAll()
+
+	assert.Len(t, errs, 2)
+	assert.True(t, errors.Is(errs[0], throw) || errors.Is(errs[1], throw))
+}
+
+func TestPanicNotRetried(t *testing.T) {
+	var calls int32
+
+	pp := pipers.FromFuncs(func() (int, error) {
+		atomic.AddInt32(&calls, 1)
+		panic("oops")
+	}).Retry(pipers.RetryPolicy{MaxAttempts: 3}).TaskTimeout(time.Second)
+
+	err := pp.FirstError()
+
+	var pe *pipers.PanicError
+	assert.True(t, errors.As(err, &pe))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kozhurkin/pipers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipeline(t *testing.T) {
+	ts := time.Now()
+
+	double := pipers.NewStage(func(ctx context.Context, n int) (int, error) {
+		<-time.After(time.Duration(5-n) * time.Millisecond)
+		return n * 2, nil
+	}).Concurrency(5)
+	format := pipers.NewStage(func(ctx context.Context, n int) (string, error) {
+		<-time.After(time.Millisecond)
+		return strconv.Itoa(n), nil
+	}).Concurrency(2)
+
+	results, err := pipers.Then(pipers.Then(pipers.Pipe([]int{1, 2, 3, 4, 5}), double), format).Ordered().Resolve()
+
+	fmt.Println(results, err, time.Since(ts))
+	// [2 4 6 8 10] <nil> 5.6ms
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2", "4", "6", "8", "10"}, results)
+	assert.GreaterOrEqual(t, time.Since(ts), 5*time.Millisecond)
+}
+
+func TestPipelineUnordered(t *testing.T) {
+	sleep := pipers.NewStage(func(ctx context.Context, n int) (int, error) {
+		<-time.After(time.Duration(n) * 25 * time.Millisecond)
+		return n, nil
+	}).Concurrency(3)
+
+	out, wait := pipers.Then(pipers.Pipe([]int{3, 1, 2}), sleep).Stream()
+	var results []int
+	for n := range out {
+		results = append(results, n)
+	}
+
+	fmt.Println(results, wait())
+	// [1 2 3] <nil>
+
+	assert.Nil(t, wait())
+	assert.Equal(t, []int{1, 2, 3}, results)
+}
+
+func TestPipelineError(t *testing.T) {
+	boom := errors.New("boom")
+	var calls int32
+
+	check := pipers.NewStage(func(ctx context.Context, n int) (int, error) {
+		if n == 3 {
+			return 0, boom
+		}
+		return n, nil
+	})
+	slow := pipers.NewStage(func(ctx context.Context, n int) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+		}
+		return n, nil
+	}).Concurrency(2)
+
+	ts := time.Now()
+	results, err := pipers.Then(pipers.Then(pipers.Pipe([]int{1, 2, 3, 4, 5, 6}), check), slow).Resolve()
+
+	fmt.Println(results, err, atomic.LoadInt32(&calls), time.Since(ts))
+	// [1 2] stage 1, item 2: boom 2 0.15ms
+
+	var se *pipers.StageError
+	assert.ErrorAs(t, err, &se)
+	assert.Equal(t, 1, se.Stage)
+	assert.Equal(t, 2, se.Index)
+	assert.ErrorIs(t, err, boom)
+	assert.LessOrEqual(t, atomic.LoadInt32(&calls), int32(2))
+	// the slow stage is canceled instead of waited for
+	assert.Less(t, time.Since(ts), 500*time.Millisecond)
+}
+
+func TestPipelinePanic(t *testing.T) {
+	parse := pipers.NewStage(func(ctx context.Context, s string) (int, error) {
+		if s == "" {
+			panic("empty input")
+		}
+		return len(s), nil
+	})
+
+	results, err := pipers.Then(pipers.Pipe([]string{"a", "bb", "", "dddd"}), parse).Ordered().Resolve()
+
+	fmt.Println(results, err)
+	// [1 2] stage 1, item 2: panic: empty input
+
+	var se *pipers.StageError
+	var pe *pipers.PanicError
+	assert.ErrorAs(t, err, &se)
+	assert.Equal(t, 2, se.Index)
+	assert.ErrorAs(t, err, &pe)
+	assert.Equal(t, 2, pe.Index)
+	assert.Equal(t, "empty input", pe.Value)
+	assert.NotEmpty(t, pe.Stack)
+}
+
+func TestPipelineBackpressure(t *testing.T) {
+	var produced int32
+
+	count := pipers.NewStage(func(ctx context.Context, n int) (int, error) {
+		atomic.AddInt32(&produced, 1)
+		return n, nil
+	}).Buffer(2)
+	slow := pipers.NewStage(func(ctx context.Context, n int) (int, error) {
+		<-time.After(5 * time.Millisecond)
+		return n, nil
+	})
+
+	out, wait := pipers.Then(pipers.Then(pipers.Pipe(make([]int, 10)), count), slow).Stream()
+	<-out
+	ahead := atomic.LoadInt32(&produced)
+	for range out {
+	}
+
+	fmt.Println(ahead, wait())
+	// 5 <nil>
+
+	assert.Nil(t, wait())
+	// one item consumed, one in the slow stage, two in the buffer, one being counted
+	assert.LessOrEqual(t, ahead, int32(5))
+	assert.Equal(t, int32(10), atomic.LoadInt32(&produced))
+}
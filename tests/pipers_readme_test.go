@@ -190,7 +190,7 @@ func TestReadmeConcurrency(t *testing.T) {
 	// 2 https://vuejs.org 360.896208ms
 	// 0 https://nodejs.org 440.650167ms
 	// 4 https://invalid.link 442.175792ms
-	// 442.23575ms [200 200 200 0 -1 0] Get "https://invalid.link": dial tcp: lookup invalid.link: no such host
+	// 442.23575ms [200 200 200 0 -1 0] task 4: Get "https://invalid.link": dial tcp: lookup invalid.link: no such host
 
 	assert.Equal(t, -1, results[4])
 	assert.NotNil(t, err)
@@ -214,7 +214,7 @@ func TestReadmeFirstNErrors(t *testing.T) {
 	results := pp.Results()
 
 	fmt.Println(results, errs)
-	// [-1 1 -1 0 0 0 0] [one three]
+	// [-1 1 -1 0 0 0 0] [task 0: one task 2: three]
 
 	assert.Equal(t, 2, len(errs))
 }
@@ -238,7 +238,7 @@ func TestReadmeErrorsAll(t *testing.T) {
 	results := pp.Results()
 
 	fmt.Println(results, errs, time.Since(ts))
-	// [-1 1 -1 1 -1 1 0] [one three five context deadline exceeded] 6.00s
+	// [-1 1 -1 1 -1 1 0] [task 0: one task 2: three task 4: five context deadline exceeded] 6.00s
 
 	assert.Equal(t, 4, len(errs))
 	assert.Equal(t, context.DeadlineExceeded, errs[3])
@@ -274,7 +274,7 @@ func TestReadmeNotEnoughtErrors(t *testing.T) {
 
 	fmt.Println(results, len(results), errs)
 	fmt.Println(results.Shift(), len(results))
-	// [throw <nil> <nil> <nil> <nil> <nil> <nil> <nil> <nil>] 9 [throw]
+	// [throw <nil> <nil> <nil> <nil> <nil> <nil> <nil> <nil>] 9 [task 0: throw]
 	// throw 8
 
 	assert.Equal(t, len(data)-1, len(results))
@@ -306,7 +306,7 @@ func TestReadmeFromArgsCtx(t *testing.T) {
 	results, err := pp.Concurrency(3).Resolve()
 
 	fmt.Println(results, err, time.Since(ts))
-	// [1 2 6 24 120 208 0 0 0] uint8 overflow 8.00s
+	// [1 2 6 24 120 208 0 0 0] task 5: uint8 overflow 8.00s
 	// break 7! iterations skipped: 1
 	// break 8! iterations skipped: 4
 
@@ -344,7 +344,7 @@ func TestReadmeFromFuncsCtx(t *testing.T) {
 	// tick
 	// tick
 	// break
-	// [true false] throw 3.00s
+	// [true false] task 0: throw 3.00s
 
 	assert.InDelta(t, 3, int(time.Since(ts).Milliseconds()), 1)
 }
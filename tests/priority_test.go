@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kozhurkin/pipers"
+	"github.com/stretchr/testify/assert"
+)
+
+// queue records the order in which tasks were started.
+type queue struct {
+	mu    sync.Mutex
+	order []int
+}
+
+func (q *queue) task(i int, d time.Duration) func() (int, error) {
+	return func() (int, error) {
+		q.mu.Lock()
+		q.order = append(q.order, i)
+		q.mu.Unlock()
+		<-time.After(d)
+		return i, nil
+	}
+}
+
+func TestPriority(t *testing.T) {
+	var q queue
+
+	pp := pipers.FromFuncs[int]().Concurrency(1)
+	for i, priority := range []int{0, 0, 1, 5, 1} {
+		pp.AddFunc(q.task(i, 2*time.Millisecond), pipers.WithPriority(priority))
+	}
+
+	results, err := pp.Resolve()
+
+	fmt.Println(results, err, q.order)
+	// [0 1 2 3 4] <nil> [3 2 4 0 1]
+
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, results)
+	assert.Equal(t, []int{3, 2, 4, 0, 1}, q.order)
+}
+
+func TestPriorityAging(t *testing.T) {
+	var q queue
+
+	pp := pipers.FromFuncs[int]().Concurrency(1).Aging(4 * time.Millisecond)
+	// every high-priority task enqueues the next one, so the queue is never free of them
+	var chain func(i int) func() (int, error)
+	chain = func(i int) func() (int, error) {
+		return func() (int, error) {
+			if i < 6 {
+				pp.AddFunc(chain(i+1), pipers.WithPriority(1))
+			}
+			return q.task(i, 5*time.Millisecond)()
+		}
+	}
+	pp.AddFunc(chain(1), pipers.WithPriority(1))
+	pp.AddFunc(q.task(0, 5*time.Millisecond))
+
+	results, err := pp.Resolve()
+
+	fmt.Println(results, err, q.order)
+	// [1 0 2 3 4 5 6] <nil> [1 2 0 3 4 5 6]
+
+	assert.Nil(t, err)
+	assert.Len(t, results, 7)
+	// the low-priority task does not wait until the queue is drained
+	assert.NotEqual(t, 0, q.order[len(q.order)-1])
+}
@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kozhurkin/pipers"
+	"github.com/stretchr/testify/assert"
+)
+
+// replica answers after d or fails with err, counting calls interrupted by cancellation.
+func replica(canceled *int32) func(ctx context.Context, i int, d time.Duration) (int, error) {
+	return func(ctx context.Context, i int, d time.Duration) (int, error) {
+		if d < 0 {
+			<-time.After(-d)
+			return 0, fmt.Errorf("replica %d is down", i)
+		}
+		select {
+		case <-time.After(d):
+			return i * 10, nil
+		case <-ctx.Done():
+			atomic.AddInt32(canceled, 1)
+			return 0, ctx.Err()
+		}
+	}
+}
+
+func TestQuorum(t *testing.T) {
+	ts := time.Now()
+	var canceled int32
+	ms := time.Millisecond
+
+	pp := pipers.FromArgsCtx([]time.Duration{4 * ms, -ms, 2 * ms, 500 * ms, 3 * ms}, replica(&canceled))
+
+	res, err := pp.Quorum(3)
+
+	fmt.Println(res, err, time.Since(ts))
+	// map[0:0 2:20 4:40] <nil> 4.1ms
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[int]int{0: 0, 2: 20, 4: 40}, res)
+	// the slow replica is not waited for
+	assert.Less(t, time.Since(ts), 250*time.Millisecond)
+
+	<-time.After(20 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&canceled))
+}
+
+func TestQuorumUnreachable(t *testing.T) {
+	ts := time.Now()
+	var canceled int32
+	ms := time.Millisecond
+
+	pp := pipers.FromArgsCtx([]time.Duration{500 * ms, -ms, -2 * ms, 2 * ms}, replica(&canceled))
+
+	res, err := pp.Quorum(3)
+
+	fmt.Println(res, err, time.Since(ts))
+	// map[] task 1: replica 1 is down
+	// task 2: replica 2 is down 2.1ms
+
+	var all *pipers.AllFailedError
+	assert.ErrorAs(t, err, &all)
+	assert.ElementsMatch(t, []int{1, 2}, all.Errs.Indexes())
+	assert.Nil(t, res)
+	assert.Less(t, time.Since(ts), 250*time.Millisecond)
+
+	_, err = pipers.FromFuncs(func() (int, error) { return 1, nil }).Quorum(2)
+	assert.EqualError(t, err, "pipers: quorum 2 of 1 tasks is unreachable")
+	assert.False(t, errors.Is(err, pipers.ErrNoTasks))
+}
+
+func TestQuorumSourceExhausted(t *testing.T) {
+	in := make(chan int, 1)
+	in <- 1
+	close(in)
+
+	res, err := pipers.FromChan(in, func(i int, a int) (int, error) { return a, nil }).Quorum(2)
+
+	fmt.Println(res, err)
+	// map[] pipers: quorum 2 is unreachable: only 1 tasks succeeded
+
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "pipers: quorum 2 is unreachable: only 1 tasks succeeded")
+	assert.False(t, errors.Is(err, pipers.ErrNoTasks))
+
+	empty := make(chan int)
+	close(empty)
+	_, err = pipers.FromChan(empty, func(i int, a int) (int, error) { return a, nil }).Quorum(2)
+	assert.Equal(t, pipers.ErrNoTasks, err)
+}
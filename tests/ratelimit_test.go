@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kozhurkin/pipers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+	ts := time.Now()
+	starts := make([]time.Duration, 10)
+
+	pp := pipers.FromArgs(make([]int, 10), func(i int, _ int) (int, error) {
+		starts[i] = time.Since(ts)
+		return i, nil
+	}).RateLimit(500, 2)
+
+	err := pp.FirstError()
+
+	fmt.Println(starts, time.Since(ts))
+	// [0s 0s 2ms 4ms 6ms 8ms 10ms 12ms 14ms 16ms] 16.00ms
+
+	assert.Nil(t, err)
+	assert.GreaterOrEqual(t, time.Since(ts), 15*time.Millisecond)
+	assert.Less(t, starts[1], time.Millisecond)
+}
+
+func TestRateLimitWithConcurrency(t *testing.T) {
+	ts := time.Now()
+	var g gauge
+
+	pp := pipers.FromArgs(make([]int, 6), func(i int, _ int) (int, error) {
+		g.enter()
+		defer g.leave()
+		<-time.After(5 * time.Millisecond)
+		return i, nil
+	}).RateLimit(1000, 6).Concurrency(2)
+
+	err := pp.FirstError()
+
+	fmt.Println(g.history, time.Since(ts))
+
+	assert.Nil(t, err)
+	for _, running := range g.history {
+		assert.LessOrEqual(t, running, 2)
+	}
+	// six tasks take three rounds with Concurrency(2)
+	assert.GreaterOrEqual(t, time.Since(ts), 15*time.Millisecond)
+}
+
+func TestRateLimiterShared(t *testing.T) {
+	ts := time.Now()
+	rl := pipers.NewRateLimiter(1000, 1)
+
+	wg := sync.WaitGroup{}
+	for k := 0; k < 2; k++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pipers.FromArgs(make([]int, 5), func(i int, _ int) (int, error) {
+				return i, nil
+			}).RateLimiter(rl).FirstError()
+		}()
+	}
+	wg.Wait()
+
+	fmt.Println(time.Since(ts))
+
+	// ten tasks of both solvers share one token per millisecond
+	assert.GreaterOrEqual(t, time.Since(ts), 8*time.Millisecond)
+}
+
+func TestRateLimitContext(t *testing.T) {
+	ts := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+
+	pp := pipers.FromArgs(make([]int, 10), func(i int, _ int) (int, error) {
+		return i, nil
+	}).RateLimit(1, 1).Context(ctx)
+
+	err := pp.FirstError()
+	<-pp.Tail()
+
+	settled := pp.Settled()
+
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, pipers.StatusSucceeded, settled[0].Status)
+	assert.Equal(t, pipers.StatusNotStarted, settled[1].Status)
+	// the next token is a second away, but the deadline comes first
+	assert.Less(t, time.Since(ts), time.Second)
+}
+
+func TestRateLimitRetry(t *testing.T) {
+	ts := time.Now()
+	var calls []time.Duration
+
+	pp := pipers.FromArgs([]int{0}, func(i int, _ int) (int, error) {
+		calls = append(calls, time.Since(ts))
+		return 0, errors.New("quota")
+	}).RateLimit(100, 1).Retry(pipers.RetryPolicy{MaxAttempts: 4})
+
+	err := pp.FirstError()
+
+	fmt.Println(calls, time.Since(ts))
+	// [0s 10ms 20ms 30ms] 30.00ms
+
+	assert.Error(t, err)
+	assert.Len(t, calls, 4)
+	// every retry waits for its own token: four calls need about three refills
+	assert.GreaterOrEqual(t, calls[3], 29*time.Millisecond)
+}
+
+func TestRateLimitHedgeRetry(t *testing.T) {
+	ts := time.Now()
+	var mu sync.Mutex
+	var calls []time.Duration
+
+	pp := pipers.FromArgs([]int{0}, func(i int, _ int) (int, error) {
+		mu.Lock()
+		calls = append(calls, time.Since(ts))
+		mu.Unlock()
+		<-time.After(5 * time.Millisecond)
+		return 0, errors.New("quota")
+	}).RateLimit(50, 1).Hedge(pipers.Hedge{Delay: time.Millisecond}).Retry(pipers.RetryPolicy{MaxAttempts: 3})
+
+	err := pp.FirstError()
+
+	fmt.Println(calls, pp.Hedges(), time.Since(ts))
+	// [0s 20ms 40ms 60ms 80ms 100ms] 3 105.00ms
+
+	assert.Error(t, err)
+	assert.Len(t, calls, 6)
+	assert.Equal(t, 3, pp.Hedges())
+	// every retry and every hedged duplicate waits for its own token
+	assert.GreaterOrEqual(t, calls[5], 99*time.Millisecond)
+}
@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kozhurkin/pipers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetry(t *testing.T) {
+	var calls [3]int32
+	args := []int{1, 2, 3}
+
+	pp := pipers.FromArgs(args, func(i int, a int) (int, error) {
+		// task i succeeds on attempt i+1
+		if atomic.AddInt32(&calls[i], 1) <= int32(i) {
+			return 0, errors.New("temporary")
+		}
+		return a * 10, nil
+	}).Retry(pipers.RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     pipers.ExponentialBackoff(time.Millisecond, 0),
+	})
+
+	results, err := pp.Resolve()
+
+	fmt.Println(results, err)
+	// [10 20 30] <nil>
+
+	assert.Nil(t, err)
+	assert.Equal(t, []int{10, 20, 30}, results)
+	assert.Equal(t, [3]int32{1, 2, 3}, calls)
+}
+
+func TestRetryExhausted(t *testing.T) {
+	throw := errors.New("throw")
+	var calls int32
+
+	pp := pipers.FromArgs([]int{1, 2}, func(i int, a int) (int, error) {
+		if i == 0 {
+			return a, nil
+		}
+		atomic.AddInt32(&calls, 1)
+		return 0, throw
+	}).Retry(pipers.RetryPolicy{MaxAttempts: 3})
+
+	errs := pp.ErrorsAll()
+
+	fmt.Println(errs, errs.Attempts())
+
+	assert.Equal(t, int32(3), calls)
+	assert.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], throw)
+	assert.Len(t, errs.Attempts()[1], 3)
+}
+
+func TestRetryPerTask(t *testing.T) {
+	fatal := errors.New("fatal")
+	var calls [2]int32
+
+	pp := pipers.FromFuncs[int]().Retry(pipers.RetryPolicy{MaxAttempts: 5})
+	pp.AddFunc(func() (int, error) {
+		atomic.AddInt32(&calls[0], 1)
+		return 0, fatal
+	}, pipers.WithRetry(pipers.RetryPolicy{
+		MaxAttempts: 5,
+		Retryable:   func(err error) bool { return !errors.Is(err, fatal) },
+	}))
+	pp.AddFunc(func() (int, error) {
+		atomic.AddInt32(&calls[1], 1)
+		return 0, errors.New("temporary")
+	})
+
+	errs := pp.ErrorsAll()
+
+	assert.Len(t, errs, 2)
+	assert.Equal(t, [2]int32{1, 5}, calls)
+}
+
+func TestRetryContextCanceled(t *testing.T) {
+	ts := time.Now()
+	var calls int32
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+
+	pp := pipers.FromArgs([]int{1}, func(i int, a int) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		return 0, errors.New("temporary")
+	}).Context(ctx).Retry(pipers.RetryPolicy{
+		MaxAttempts: 10,
+		Backoff:     pipers.ConstantBackoff(time.Hour),
+	})
+
+	err := pp.FirstError()
+	<-pp.Tail()
+
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	// the hour-long backoff is cut short by the deadline
+	assert.Less(t, time.Since(ts), time.Second)
+}
+
+func TestBackoff(t *testing.T) {
+	exp := pipers.ExponentialBackoff(10*time.Millisecond, 50*time.Millisecond)
+	assert.Equal(t, 10*time.Millisecond, exp(1))
+	assert.Equal(t, 20*time.Millisecond, exp(2))
+	assert.Equal(t, 40*time.Millisecond, exp(3))
+	assert.Equal(t, 50*time.Millisecond, exp(4))
+	assert.Equal(t, 50*time.Millisecond, exp(100))
+
+	jitter := pipers.FullJitter(exp)
+	for attempt := 1; attempt < 10; attempt++ {
+		assert.Less(t, jitter(attempt), exp(attempt))
+	}
+}
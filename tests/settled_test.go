@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kozhurkin/pipers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettled(t *testing.T) {
+	delays := []int{30, 300, 20, 300, 10, 300, 10}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	pp := pipers.FromArgsCtx(delays, func(ctx context.Context, i int, delay int) (int, error) {
+		if i == 4 {
+			return 0, errors.New("throw")
+		}
+		select {
+		case <-time.After(time.Duration(delay) * time.Millisecond):
+			return 0, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}).Context(ctx).Concurrency(3)
+
+	pp.ErrorsAll()
+	<-pp.Tail()
+	settled := pp.Settled()
+
+	for _, s := range settled {
+		fmt.Println(s.Index, s.Status, s.Value, s.Err, s.Attempts, s.Duration())
+	}
+
+	assert.Equal(t, pipers.StatusSucceeded, settled[0].Status)
+	assert.Equal(t, pipers.StatusCanceled, settled[1].Status)
+	assert.Equal(t, pipers.StatusSucceeded, settled[2].Status)
+	assert.Equal(t, pipers.StatusFailed, settled[4].Status)
+	assert.Equal(t, pipers.StatusNotStarted, settled[6].Status)
+	assert.Equal(t, 1, settled[0].Attempts)
+	assert.Equal(t, 0, settled[6].Attempts)
+	assert.False(t, settled[0].End.Before(settled[0].Start))
+	assert.GreaterOrEqual(t, settled[0].Duration(), 30*time.Millisecond)
+	assert.True(t, settled[6].Start.IsZero())
+}
+
+func TestSettledRunning(t *testing.T) {
+	release := make(chan struct{})
+	var calls int32
+
+	pp := pipers.FromFuncs(
+		func() (int, error) { <-release; return 1, nil },
+		func() (int, error) {
+			if atomic.AddInt32(&calls, 1) < 3 {
+				return 0, errors.New("temporary")
+			}
+			return 2, nil
+		},
+	).Retry(pipers.RetryPolicy{MaxAttempts: 3})
+
+	go func() {
+		<-time.After(2 * time.Millisecond)
+		settled := pp.Settled()
+		assert.Equal(t, pipers.StatusRunning, settled[0].Status)
+		assert.Equal(t, pipers.StatusSucceeded, settled[1].Status)
+		assert.Equal(t, 3, settled[1].Attempts)
+		assert.Equal(t, 2, settled[1].Value)
+		close(release)
+	}()
+
+	results, err := pp.Resolve()
+
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2}, results)
+}
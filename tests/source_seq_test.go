@@ -0,0 +1,53 @@
+//go:build go1.23
+
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kozhurkin/pipers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromSeq(t *testing.T) {
+	var pulled int
+	seq := func(yield func(int) bool) {
+		for i := 0; ; i++ {
+			pulled++
+			if !yield(i) {
+				return
+			}
+		}
+	}
+
+	pp := pipers.FromSeq(seq, func(i int, a int) (int, error) {
+		if a == 5 {
+			return 0, errors.New("throw")
+		}
+		return a * 2, nil
+	}).Concurrency(1)
+
+	results, err := pp.Resolve()
+
+	assert.ErrorContains(t, err, "task 5: throw")
+	assert.Equal(t, []int{0, 2, 4, 6, 8, 0}, []int(results[:6]))
+	assert.LessOrEqual(t, pulled, 7)
+}
+
+func TestFromSeqFinite(t *testing.T) {
+	seq := func(yield func(string) bool) {
+		for _, s := range []string{"a", "bb", "ccc"} {
+			if !yield(s) {
+				return
+			}
+		}
+	}
+
+	results, err := pipers.FromSeq(seq, func(i int, s string) (int, error) {
+		return len(s), nil
+	}).Resolve()
+
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 3}, results)
+}
@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kozhurkin/pipers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromChan(t *testing.T) {
+	in := make(chan int)
+	go func() {
+		defer close(in)
+		for i := 1; i <= 5; i++ {
+			in <- i
+		}
+	}()
+
+	pp := pipers.FromChan(in, func(i int, a int) (int, error) {
+		<-time.After(time.Millisecond)
+		return a * a, nil
+	}).Concurrency(2)
+
+	results, err := pp.Resolve()
+
+	fmt.Println(results, err)
+	// [1 4 9 16 25] <nil>
+
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 4, 9, 16, 25}, []int(results))
+}
+
+func TestFromChanBackpressure(t *testing.T) {
+	var read, readAhead, running, maxRunning int32
+	in := make(chan int)
+	quit := make(chan struct{})
+	go func() {
+		defer close(in)
+		for i := 0; i < 20; i++ {
+			select {
+			case in <- i:
+				atomic.AddInt32(&read, 1)
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	pp := pipers.FromChan(in, func(i int, a int) (int, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for m := atomic.LoadInt32(&maxRunning); n > m && !atomic.CompareAndSwapInt32(&maxRunning, m, n); {
+			m = atomic.LoadInt32(&maxRunning)
+		}
+		if a == 0 {
+			// the first task holds a slot, the second one keeps turning over
+			<-time.After(5 * time.Millisecond)
+			atomic.StoreInt32(&readAhead, atomic.LoadInt32(&read))
+		}
+		<-time.After(time.Millisecond)
+		return a, nil
+	}).Concurrency(2)
+
+	errs := pp.ErrorsAll()
+	close(quit)
+
+	assert.Nil(t, errs)
+	assert.Equal(t, int32(20), atomic.LoadInt32(&read))
+	assert.LessOrEqual(t, atomic.LoadInt32(&readAhead), int32(8))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&maxRunning))
+	assert.Len(t, pp.Results(), 20)
+}
+
+func TestFromChanErrorLimit(t *testing.T) {
+	in := make(chan int)
+	quit := make(chan struct{})
+	go func() {
+		defer close(in)
+		for i := 0; ; i++ {
+			select {
+			case in <- i:
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	pp := pipers.FromChanCtx(in, func(ctx context.Context, i int, a int) (int, error) {
+		if a == 3 {
+			return 0, errors.New("throw")
+		}
+		return a, nil
+	}).Concurrency(1)
+
+	results, err := pp.Resolve()
+	close(quit)
+	<-pp.Tail()
+
+	fmt.Println(results, err)
+	// [0 1 2 0] task 3: throw
+
+	assert.EqualError(t, err, "task 3: throw")
+	assert.Equal(t, []int{0, 1, 2, 0}, []int(results[:4]))
+}
+
+func TestFromChanContext(t *testing.T) {
+	in := make(chan int)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Millisecond)
+	defer cancel()
+
+	pp := pipers.FromChan(in, func(i int, a int) (int, error) {
+		return a, nil
+	}).Context(ctx)
+
+	go func() { in <- 1 }()
+
+	errs := pp.ErrorsAll()
+
+	assert.Equal(t, pipers.Errors{context.DeadlineExceeded}, errs)
+	assert.Equal(t, []int{1}, []int(pp.Results()))
+}
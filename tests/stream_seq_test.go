@@ -0,0 +1,36 @@
+//go:build go1.23
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kozhurkin/pipers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamSeq(t *testing.T) {
+	pp := pipers.FromArgsCtx([]int{60, 20, 40, 500, 500}, func(ctx context.Context, i int, delay int) (int, error) {
+		select {
+		case <-time.After(time.Duration(delay) * time.Millisecond):
+			return delay, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}).Concurrency(3)
+
+	var indexes []int
+	for i, s := range pp.StreamSeq(0) {
+		assert.Nil(t, s.Err)
+		if indexes = append(indexes, i); len(indexes) == 2 {
+			break
+		}
+	}
+
+	<-pp.Tail()
+
+	assert.Equal(t, []int{1, 2}, indexes)
+	assert.Equal(t, pipers.StatusCanceled, pp.Settled()[3].Status)
+}
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kozhurkin/pipers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStream(t *testing.T) {
+	ts := time.Now()
+	delays := []int{100, 25, 75, 50}
+
+	pp := pipers.FromArgs(delays, func(i int, delay int) (int, error) {
+		<-time.After(time.Duration(delay) * time.Millisecond)
+		return delay, nil
+	})
+
+	var order []int
+	for s := range pp.Stream(0) {
+		fmt.Println(s.Index, s.Value, s.Err, time.Since(ts))
+		order = append(order, s.Value)
+	}
+
+	assert.Equal(t, []int{25, 50, 75, 100}, order)
+}
+
+func TestStreamConcurrency(t *testing.T) {
+	delays := []int{40, 5, 5, 5}
+
+	pp := pipers.FromArgs(delays, func(i int, delay int) (int, error) {
+		<-time.After(time.Duration(delay) * time.Millisecond)
+		return i, nil
+	}).Concurrency(2)
+
+	var order []int
+	for s := range pp.Stream(0) {
+		order = append(order, s.Index)
+	}
+
+	assert.Equal(t, []int{1, 2, 3, 0}, order)
+}
+
+func TestStreamErrorLimit(t *testing.T) {
+	data := []int{1, 2, 3, 4, 5, 6, 7}
+
+	pp := pipers.FromArgsCtx(data, func(ctx context.Context, i int, a int) (int, error) {
+		if a%2 == 0 {
+			return 0, errors.New("even")
+		}
+		return a, nil
+	}).Concurrency(1)
+
+	var settled []pipers.Settlement[int]
+	for s := range pp.Stream(2) {
+		settled = append(settled, s)
+	}
+
+	fmt.Println(settled)
+
+	assert.Len(t, settled, 4)
+	assert.Equal(t, pipers.StatusFailed, settled[3].Status)
+	assert.Equal(t, pipers.StatusNotStarted, pp.Settled()[4].Status)
+}
+
+func TestStreamContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	pp := pipers.FromArgsCtx([]int{10, 100, 100, 100}, func(ctx context.Context, i int, delay int) (int, error) {
+		select {
+		case <-time.After(time.Duration(delay) * time.Millisecond):
+			return delay, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}).Context(ctx).Concurrency(2)
+
+	statuses := map[pipers.Status]int{}
+	for s := range pp.Stream(0) {
+		statuses[s.Status]++
+	}
+
+	fmt.Println(statuses)
+
+	assert.Equal(t, map[pipers.Status]int{pipers.StatusSucceeded: 1, pipers.StatusCanceled: 2}, statuses)
+}
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kozhurkin/pipers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskTimeout(t *testing.T) {
+	ts := time.Now()
+	delays := []int{1, 500, 2}
+
+	pp := pipers.FromArgsCtx(delays, func(ctx context.Context, i int, delay int) (int, error) {
+		select {
+		case <-time.After(time.Duration(delay) * time.Millisecond):
+			return delay, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}).TaskTimeout(20 * time.Millisecond)
+
+	errs := pp.ErrorsAll()
+	results := pp.Results()
+
+	fmt.Println(results, errs, time.Since(ts))
+	// [1 0 2] [task 1: pipers: task timeout (20ms)] 20.00ms
+
+	var te *pipers.TimeoutError
+	assert.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], pipers.ErrTaskTimeout)
+	assert.False(t, errors.Is(errs[0], context.DeadlineExceeded))
+	assert.True(t, errors.As(errs[0], &te))
+	assert.Equal(t, 1, te.Index)
+	assert.Equal(t, []int{1, 0, 2}, []int(results))
+	// the slow task is abandoned long before it would have finished
+	assert.Less(t, time.Since(ts), 250*time.Millisecond)
+}
+
+func TestTaskTimeoutPerTask(t *testing.T) {
+	ts := time.Now()
+
+	pp := pipers.FromFuncs[int]().TaskTimeout(time.Second)
+	pp.AddFunc(func() (int, error) {
+		<-time.After(3 * time.Millisecond)
+		return 1, nil
+	})
+	pp.AddFuncCtx(func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 2, ctx.Err()
+	}, pipers.WithTimeout(time.Millisecond))
+
+	results, err := pp.Resolve()
+
+	fmt.Println(results, err, time.Since(ts))
+
+	assert.ErrorIs(t, err, pipers.ErrTaskTimeout)
+	// WithTimeout overrides the solver-wide one second timeout
+	assert.Less(t, time.Since(ts), 500*time.Millisecond)
+
+	<-pp.Tail()
+}
+
+func TestTaskTimeoutParentDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Millisecond)
+	defer cancel()
+
+	pp := pipers.FromArgsCtx([]int{1}, func(ctx context.Context, i int, a int) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}).Context(ctx).TaskTimeout(time.Second)
+
+	errs := pp.ErrorsAll()
+	<-pp.Tail()
+
+	fmt.Println(errs)
+
+	assert.False(t, errors.Is(errs[0], pipers.ErrTaskTimeout))
+	assert.ErrorIs(t, errs[0], context.DeadlineExceeded)
+}
+
+func TestTaskTimeoutConcurrency(t *testing.T) {
+	var g gauge
+
+	pp := pipers.FromArgs(make([]int, 6), func(i int, _ int) (int, error) {
+		g.enter()
+		defer g.leave()
+		// ignores the context and keeps running after the timeout
+		<-time.After(10 * time.Millisecond)
+		return i, nil
+	}).Concurrency(2).TaskTimeout(2 * time.Millisecond)
+
+	errs := pp.ErrorsAll()
+	<-time.After(20 * time.Millisecond)
+
+	peak := g.peak()
+	fmt.Println(len(errs), peak)
+	// 6 2
+
+	assert.Len(t, errs, 6)
+	for _, err := range errs {
+		assert.ErrorIs(t, err, pipers.ErrTaskTimeout)
+	}
+	assert.Equal(t, 2, peak)
+	assert.Len(t, g.history, 6)
+}
@@ -0,0 +1,144 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kozhurkin/pipers"
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder is a Tracer that keeps every span it starts.
+type recorder struct {
+	mu    sync.Mutex
+	spans []*span
+}
+
+type spanKey struct{}
+
+type span struct {
+	rec    *recorder
+	name   string
+	parent *span
+	attrs  map[string]any
+	events map[string]map[string]any
+	err    error
+	ended  bool
+}
+
+func pairs(kv []any) map[string]any {
+	m := make(map[string]any)
+	for k := 0; k+1 < len(kv); k += 2 {
+		m[kv[k].(string)] = kv[k+1]
+	}
+	return m
+}
+
+func (r *recorder) Start(ctx context.Context, name string, attrs ...any) (context.Context, pipers.Span) {
+	s := &span{rec: r, name: name, attrs: pairs(attrs), events: make(map[string]map[string]any)}
+	s.parent, _ = ctx.Value(spanKey{}).(*span)
+	r.mu.Lock()
+	r.spans = append(r.spans, s)
+	r.mu.Unlock()
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+func (s *span) AddEvent(name string, attrs ...any) {
+	s.rec.mu.Lock()
+	defer s.rec.mu.Unlock()
+	s.events[name] = pairs(attrs)
+}
+
+func (s *span) End(err error) {
+	s.rec.mu.Lock()
+	defer s.rec.mu.Unlock()
+	s.err, s.ended = err, true
+}
+
+// named returns the spans with the given name ordered by their index attribute.
+func (r *recorder) named(name string) []*span {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var res []*span
+	for _, s := range r.spans {
+		if s.name == name {
+			res = append(res, s)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		a, _ := res[i].attrs["index"].(int)
+		b, _ := res[j].attrs["index"].(int)
+		return a < b
+	})
+	return res
+}
+
+func TestTracer(t *testing.T) {
+	var rec recorder
+	boom := errors.New("boom")
+
+	parents := make([]*span, 3)
+	pp := pipers.FromArgsCtx([]int{10, 20, 30}, func(ctx context.Context, i int, ms int) (int, error) {
+		parents[i], _ = ctx.Value(spanKey{}).(*span)
+		<-time.After(time.Duration(ms) * time.Millisecond)
+		if i == 1 {
+			return 0, boom
+		}
+		return ms, nil
+	}).Tracer(&rec).Concurrency(1)
+
+	errs := pp.ErrorsAll()
+
+	batch := rec.named("pipers.batch")
+	tasks := rec.named("pipers.task")
+	fmt.Println(len(batch), len(tasks), errs)
+	// 1 3 [task 1: boom]
+
+	assert.Len(t, batch, 1)
+	assert.Len(t, tasks, 3)
+	assert.Equal(t, 3, batch[0].attrs["tasks"])
+	assert.True(t, batch[0].ended)
+	assert.ErrorIs(t, batch[0].err, boom)
+
+	for i, s := range tasks {
+		assert.Equal(t, i, s.attrs["index"])
+		assert.Equal(t, batch[0], s.parent)
+		assert.Equal(t, s, parents[i])
+		assert.True(t, s.ended)
+		assert.Equal(t, 1, s.events["finished"]["attempts"])
+	}
+	assert.NoError(t, tasks[0].err)
+	assert.ErrorIs(t, tasks[1].err, boom)
+
+	// with Concurrency(1) each task waits for the previous ones
+	waits := []time.Duration{0, 10 * time.Millisecond, 30 * time.Millisecond}
+	runs := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 30 * time.Millisecond}
+	for i, s := range tasks {
+		assert.GreaterOrEqual(t, s.events["queued"]["wait"].(time.Duration), waits[i])
+		assert.GreaterOrEqual(t, s.events["finished"]["run"].(time.Duration), runs[i])
+	}
+}
+
+func TestTracerResolve(t *testing.T) {
+	var rec recorder
+
+	res, err := pipers.FromFuncsCtx(
+		func(ctx context.Context) (int, error) { return 1, nil },
+		func(ctx context.Context) (int, error) { return 2, nil },
+	).Tracer(&rec).Resolve()
+
+	batch := rec.named("pipers.batch")
+	fmt.Println(res, err, len(batch), len(rec.named("pipers.task")))
+	// [1 2] <nil> 1 2
+
+	assert.Equal(t, []int{1, 2}, res)
+	assert.NoError(t, err)
+	assert.Len(t, batch, 1)
+	assert.Nil(t, batch[0].err)
+	assert.Equal(t, 1, batch[0].attrs["errlimit"])
+}
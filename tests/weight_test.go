@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kozhurkin/pipers"
+	"github.com/stretchr/testify/assert"
+)
+
+// load tracks the total weight of the tasks running at once.
+type load struct {
+	mu      sync.Mutex
+	current int64
+	max     int64
+	order   []int
+}
+
+func (l *load) enter(i int, w int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.current += w
+	if l.current > l.max {
+		l.max = l.current
+	}
+	l.order = append(l.order, i)
+}
+
+func (l *load) leave(w int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.current -= w
+}
+
+func TestWeightLimit(t *testing.T) {
+	var l load
+	sizes := []int64{2, 2, 5, 1, 1, 3}
+
+	pp := pipers.FromArgsWeighted(sizes, func(size int64) int64 { return size }, func(i int, size int64) (int64, error) {
+		l.enter(i, size)
+		defer l.leave(size)
+		<-time.After(2 * time.Millisecond)
+		return size, nil
+	}).WeightLimit(5)
+
+	results, err := pp.Resolve()
+
+	fmt.Println(results, err, l.order, l.max)
+	// [2 2 5 1 1 3] <nil> [0 1 2 3 4 5] 5
+
+	assert.Nil(t, err)
+	// the heavy task is not overtaken by the light ones queued after it
+	assert.Equal(t, 2, l.order[2])
+	assert.Equal(t, int64(5), l.max)
+}
+
+func TestWeightLimitOversized(t *testing.T) {
+	ts := time.Now()
+	var l load
+
+	pp := pipers.FromFuncs[int]().WeightLimit(4)
+	for i, w := range []int64{1, 10, 1} {
+		i, w := i, w
+		pp.AddFuncWeighted(w, func() (int, error) {
+			l.enter(i, w)
+			defer l.leave(w)
+			<-time.After(2 * time.Millisecond)
+			return i, nil
+		})
+	}
+
+	err := pp.FirstError()
+
+	fmt.Println(l.order, l.max, time.Since(ts))
+	// [0 1 2] 10 6.00ms
+
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0, 1, 2}, l.order)
+	assert.Equal(t, int64(10), l.max)
+	assert.GreaterOrEqual(t, time.Since(ts), 6*time.Millisecond)
+}
+
+func TestWeightLimitWithConcurrency(t *testing.T) {
+	var g gauge
+
+	pp := pipers.FromArgs(make([]int, 8), func(i int, _ int) (int, error) {
+		g.enter()
+		defer g.leave()
+		<-time.After(time.Millisecond)
+		return i, nil
+	}).WeightLimit(100).Concurrency(3)
+
+	err := pp.FirstError()
+
+	assert.Nil(t, err)
+	for _, running := range g.history {
+		assert.LessOrEqual(t, running, 3)
+	}
+}
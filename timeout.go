@@ -0,0 +1,66 @@
+package pipers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrTaskTimeout — причина TimeoutError, удобна для проверки через errors.Is.
+var ErrTaskTimeout = errors.New("pipers: task timeout")
+
+// TimeoutError возвращается задачей, не уложившейся в отведённое ей время
+// (см. FliperSolver.TaskTimeout и WithTimeout).
+type TimeoutError struct {
+	Index   int
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%v (%v)", ErrTaskTimeout, e.Timeout)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return ErrTaskTimeout
+}
+
+// runTimeout выполняет fn с дочерним контекстом, ограниченным по времени d.
+// Если время вышло раньше, чем fn вернула результат, возвращается TimeoutError,
+// а fn продолжает работать в фоне и её результат отбрасывается; пока fn не вернётся,
+// задача удерживает свои слоты в планировщике (см. held).
+// Завершение родительского контекста таймаутом не считается: в этом случае
+// результат fn дожидается как обычно.
+func (t *task[T]) runTimeout(parent context.Context, d time.Duration, fn func(context.Context) (T, error)) (T, error) {
+	ctx, cancel := context.WithTimeout(parent, d)
+	defer cancel()
+
+	type result struct {
+		res T
+		err error
+	}
+	ch := make(chan result, 1)
+	t.spawn(func() {
+		res, err := fn(ctx)
+		ch <- result{res, err}
+	})
+
+	expired := func() bool {
+		return parent.Err() == nil && errors.Is(ctx.Err(), context.DeadlineExceeded)
+	}
+
+	select {
+	case r := <-ch:
+		if r.err != nil && expired() {
+			return r.res, &TimeoutError{Index: t.index, Timeout: d}
+		}
+		return r.res, r.err
+	case <-ctx.Done():
+		if expired() {
+			var zero T
+			return zero, &TimeoutError{Index: t.index, Timeout: d}
+		}
+		r := <-ch
+		return r.res, r.err
+	}
+}
@@ -0,0 +1,68 @@
+package pipers
+
+import (
+	"context"
+	"time"
+)
+
+// Tracer создаёт span трассировки (см. FliperSolver.Tracer). attrs — пары ключ-значение,
+// как в log/slog. Возвращаемый контекст должен нести созданный span,
+// чтобы span, открытые с этим контекстом, стали его дочерними.
+// Адаптер для OpenTelemetry — пакет github.com/kozhurkin/pipers/otelpipers.
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs ...any) (context.Context, Span)
+}
+
+// Span — открытый span трассировки. End завершает его с ошибкой операции (nil — без ошибки).
+type Span interface {
+	AddEvent(name string, attrs ...any)
+	End(err error)
+}
+
+// Tracer включает трассировку: FirstNErrors (а значит, и FirstError, ErrorsAll и Resolve)
+// открывает span "pipers.batch", а каждая задача — дочерний span "pipers.task"
+// с атрибутом index. Span задачи передаётся обработчикам AddFuncCtx и FromArgsCtx
+// через контекст. В span задачи записываются события "queued" со временем ожидания
+// в очереди (wait) и "finished" со временем выполнения (run) и числом попыток (attempts).
+// К Flight, добавленным через Add, трассировка не применяется.
+func (ps *FliperSolver[T]) Tracer(t Tracer) *FliperSolver[T] {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.tracer = t
+	return ps
+}
+
+// traceBatch открывает span пакета задач и делает его контекст контекстом решателя,
+// чтобы span задач стали дочерними. Возвращает функцию, завершающую span.
+func (ps *FliperSolver[T]) traceBatch(ctx context.Context, errlimit int) (context.Context, func(Errors)) {
+	ps.mu.Lock()
+	tracer := ps.tracer
+	tasks := len(ps.flipers)
+	ps.mu.Unlock()
+	if tracer == nil {
+		return ctx, func(Errors) {}
+	}
+	ctx, span := tracer.Start(ctx, "pipers.batch", "tasks", tasks, "errlimit", errlimit)
+	ps.context = ctx
+	return ctx, func(errs Errors) {
+		span.End(errs.Join())
+	}
+}
+
+// traced выполняет задачу в её собственном span.
+func (ps *FliperSolver[T]) traced(t *task[T], run func(ctx context.Context) (T, error)) (T, error) {
+	ps.mu.Lock()
+	tracer := ps.tracer
+	ps.mu.Unlock()
+	if tracer == nil {
+		return run(ps.context)
+	}
+
+	ctx, span := tracer.Start(ps.context, "pipers.task", "index", t.index)
+	span.AddEvent("queued", "wait", time.Since(t.queued))
+	start := time.Now()
+	res, err := run(ctx)
+	span.AddEvent("finished", "run", time.Since(start), "attempts", t.attempted())
+	span.End(err)
+	return res, err
+}